@@ -0,0 +1,10 @@
+package metrics
+
+// Unregister removes the most recently registered metric so tests leave the registry as found
+func Unregister(m Metric) {
+	registry.Lock()
+	defer registry.Unlock()
+	if int(m) == len(registry.mets)-1 {
+		registry.mets = registry.mets[:m]
+	}
+}
@@ -1,87 +1,120 @@
 package metrics
 
 import (
+	"math"
 	"strings"
 
 	"github.com/rhagenson/swsc/internal/entropy"
 	"github.com/rhagenson/swsc/internal/nexus"
 )
 
-// Metric is an enum type denoting possible sitewise metrics to calculate
+// Metric identifies a registered SitewiseMetric
 type Metric int
 
-const (
+// Built-in metrics, registered in this order so their values are stable
+var (
 	// Entropy is Shannon's entropy
-	Entropy Metric = iota
+	Entropy = Register(entropyMetric{})
 
 	// GC is GC percentage
-	GC
+	GC = Register(gcMetric{})
 
 	// Multi is the multinomial measurement
-	Multi
+	Multi = Register(multiMetric{})
 )
 
+// String is the name of the registered metric, empty if it is not registered
 func (m Metric) String() string {
-	switch m {
-	case Entropy:
-		return "Entropy"
-	case GC:
-		return "GC"
-	case Multi:
-		return "Multinomial"
-	default:
-		return ""
+	if sm, ok := Get(m); ok {
+		return sm.Name()
 	}
+	return ""
 }
 
-// func sitewiseMulti(uceAln nexus.Alignment) []float64 {
-// 	uceCounts := sitewiseBaseCounts(uceAln)
-// 	uceSums := make([]float64, len(uceCounts))
-// 	for i := range uceSums {
-// 		for _, v := range uceCounts {
-// 			for _, z := range v {
-// 				uceSums[i] += float64(z)
-// 			}
-// 		}
-// 	}
-// 	uceObsFactorial := make([]float64, len(uceSums))
-// 	for i, v := range uceSums {
-// 		uceObsFactorial[i] = factorial(int(v))
-// 	}
-// 	uceFactorials := factorialMatrix(uceCounts)
-
-// 	m1 := minFloat64(uceObsFactorial...)
-// 	m2 := minFloat64(uceFactorials...)
-// 	if m1 < 0 || m2 < 0 {
-// 		panic("Sitewise multinomial factorials <0")
-// 	}
-// 	sitewiseLikelihood := sitewiseMultiCounts(uceCounts, uceFactorials, uceObsFactorial)
-// 	logLikelihoods := make([]float64, len(sitewiseLikelihood))
-// 	for i, v := range sitewiseLikelihood {
-// 		logLikelihoods[i] = math.Log(v)
-// 	}
-// 	return logLikelihoods
-// }
-
-// // Was not in the sample code
-// // Implemented similar to sitewiseBaseCount
-// func sitewiseMultiCounts(uceAln nexus.Alignment) map[byte][]float64 {
-// 	counts := map[byte][]float64{
-// 		'A': make([]float64, uceAln.Len()),
-// 		'T': make([]float64, uceAln.Len()),
-// 		'C': make([]float64, uceAln.Len()),
-// 		'G': make([]float64, uceAln.Len()),
-// 	}
-// 	for i := 0; i < uceAln.Len(); i++ {
-// 		site, _ := uceAln.Subseq(i, i+1)
-// 		bCounts := sitewiseMulti(site)
-// 		for k, v := range bCounts {
-// 			counts[k][i] += v
-// 		}
-// 	}
-// 	return counts
-// }
+type entropyMetric struct{}
 
+func (entropyMetric) Name() string                 { return "Entropy" }
+func (entropyMetric) Flag() string                 { return "entropy" }
+func (entropyMetric) Usage() string                { return "Calculate Shannon's entropy metric" }
+func (entropyMetric) Supports(letters []byte) bool { return len(letters) != 0 }
+func (entropyMetric) Compute(aln *nexus.Alignment, letters []byte) []float64 {
+	return SitewiseEntropy(aln, letters)
+}
+
+type gcMetric struct{}
+
+func (gcMetric) Name() string  { return "GC" }
+func (gcMetric) Flag() string  { return "gc" }
+func (gcMetric) Usage() string { return "Calculate GC content metric" }
+
+// Supports only nucleotide alphabets, G and C are also amino acids
+func (gcMetric) Supports(letters []byte) bool {
+	upper := strings.ToUpper(string(letters))
+	if !strings.ContainsRune(upper, 'G') || !strings.ContainsRune(upper, 'C') {
+		return false
+	}
+	for _, l := range upper {
+		if !strings.ContainsRune("ACGTU", l) {
+			return false
+		}
+	}
+	return true
+}
+func (gcMetric) Compute(aln *nexus.Alignment, letters []byte) []float64 {
+	return SitewiseGc(aln)
+}
+
+type multiMetric struct{}
+
+func (multiMetric) Name() string                 { return "Multinomial" }
+func (multiMetric) Flag() string                 { return "multi" }
+func (multiMetric) Usage() string                { return "Calculate multinomial distribution metric" }
+func (multiMetric) Supports(letters []byte) bool { return len(letters) != 0 }
+func (multiMetric) Compute(aln *nexus.Alignment, letters []byte) []float64 {
+	return SitewiseMulti(aln, letters)
+}
+
+// SitewiseMulti returns the log-likelihood of each site's character counts
+// under a multinomial distribution parameterised by the character frequencies of aln, a single UCE in swsc.
+// Factorials are computed in log-space via math.Lgamma so large taxon counts do not overflow.
+func SitewiseMulti(aln *nexus.Alignment, letters []byte) []float64 {
+	counts := SitewiseBaseCounts(aln, letters)
+	freqs := make(map[byte]float64, len(letters))
+	grand := 0
+	for _, l := range letters {
+		for _, n := range counts[l] {
+			freqs[l] += float64(n)
+			grand += n
+		}
+	}
+	for _, l := range letters {
+		if grand != 0 {
+			freqs[l] /= float64(grand)
+		}
+	}
+	logLiks := make([]float64, aln.Len())
+	for i := range logLiks {
+		total := 0
+		for _, l := range letters {
+			n := counts[l][i]
+			total += n
+			if n == 0 {
+				continue // 0! == 1 and p^0 == 1, contributes nothing (even if p == 0)
+			}
+			logLiks[i] += float64(n)*math.Log(freqs[l]) - logFactorial(n)
+		}
+		logLiks[i] += logFactorial(total)
+	}
+	return logLiks
+}
+
+// logFactorial is ln(n!) computed via the log-gamma function
+func logFactorial(n int) float64 {
+	lg, _ := math.Lgamma(float64(n) + 1)
+	return lg
+}
+
+// SitewiseEntropy returns Shannon's entropy of each site
 func SitewiseEntropy(aln *nexus.Alignment, chars []byte) []float64 {
 	entropies := make([]float64, aln.Len())
 	for i := 0; i < aln.Len(); i++ {
@@ -101,12 +134,15 @@ func SitewiseBaseCounts(aln *nexus.Alignment, letters []byte) map[byte][]int {
 		site := aln.Subseq(i, i+1)
 		bCounts := site.Count(letters)
 		for k, v := range bCounts {
-			counts[k][i] += v
+			if _, ok := counts[k]; ok { // Gap, missing, and ambiguous characters are not counted
+				counts[k][i] += v
+			}
 		}
 	}
 	return counts
 }
 
+// SitewiseGc returns the GC proportion of each site
 func SitewiseGc(aln *nexus.Alignment) []float64 {
 	gc := make([]float64, aln.Len())
 	for i := range gc {
@@ -1,6 +1,7 @@
 package metrics_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/rhagenson/swsc/internal/metrics"
@@ -383,3 +384,109 @@ func TestSitewiseGc(t *testing.T) {
 		})
 	}
 }
+
+func TestSitewiseMulti(t *testing.T) {
+	tt := []struct {
+		aln   nexus.Alignment
+		chars []byte
+		exp   []float64
+	}{
+		{ // All the same seq
+			nexus.Alignment([]string{
+				"ATGCAT",
+				"ATGCAT",
+				"ATGCAT",
+			}),
+			[]byte("ATGC"),
+			[]float64{
+				3 * math.Log(6.0/18.0),
+				3 * math.Log(6.0/18.0),
+				3 * math.Log(3.0/18.0),
+				3 * math.Log(3.0/18.0),
+				3 * math.Log(6.0/18.0),
+				3 * math.Log(6.0/18.0),
+			},
+		},
+		{ // Not all bases present
+			nexus.Alignment([]string{
+				"CCCCCC",
+				"TTTTTT",
+				"GGGGGG",
+			}),
+			[]byte("ATGC"),
+			[]float64{
+				math.Log(6) + 3*math.Log(1.0/3.0),
+				math.Log(6) + 3*math.Log(1.0/3.0),
+				math.Log(6) + 3*math.Log(1.0/3.0),
+				math.Log(6) + 3*math.Log(1.0/3.0),
+				math.Log(6) + 3*math.Log(1.0/3.0),
+				math.Log(6) + 3*math.Log(1.0/3.0),
+			},
+		},
+		{ // All the same base
+			nexus.Alignment([]string{
+				"CCCCCC",
+				"CCCCCC",
+				"CCCCCC",
+			}),
+			[]byte("ATGC"),
+			[]float64{
+				0.0,
+				0.0,
+				0.0,
+				0.0,
+				0.0,
+				0.0,
+			},
+		},
+		{ // Gaps are not counted
+			nexus.Alignment([]string{
+				"AA-",
+				"AAT",
+				"AAT",
+			}),
+			[]byte("ATGC"),
+			[]float64{
+				3 * math.Log(6.0/8.0),
+				3 * math.Log(6.0/8.0),
+				2 * math.Log(2.0/8.0),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		got := metrics.SitewiseMulti(&tc.aln, tc.chars)
+		t.Run("Length", func(t *testing.T) {
+			if len(got) != len(tc.exp) {
+				t.Errorf("Lengths do not match. Got %d, expected %d",
+					len(got), len(tc.exp),
+				)
+			}
+		})
+		t.Run("Match", func(t *testing.T) {
+			for i := range got {
+				if !floats.EqualWithinAbs(got[i], tc.exp[i], 1e-10) {
+					t.Errorf("Got %.5f, expected %.5f", got[i], tc.exp[i])
+				}
+			}
+		})
+	}
+}
+
+// TestSitewiseMultiLargeN ensures the log-space factorials do not overflow
+func TestSitewiseMultiLargeN(t *testing.T) {
+	seqs := make([]string, 500)
+	for i := range seqs {
+		if i%2 == 0 {
+			seqs[i] = "AC"
+		} else {
+			seqs[i] = "TG"
+		}
+	}
+	aln := nexus.Alignment(seqs)
+	for i, v := range metrics.SitewiseMulti(&aln, []byte("ATGC")) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Errorf("Site %d: got non-finite log-likelihood %v", i, v)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+// SitewiseMetric is a characteristic computed independently at each site of an alignment
+// Implementations are made available to the CLI by passing them to Register
+type SitewiseMetric interface {
+	// Name is the human-readable name of the metric (e.g. "Entropy")
+	Name() string
+	// Flag is the command line flag which enables the metric (e.g. "entropy")
+	Flag() string
+	// Usage is the command line help text for the metric
+	Usage() string
+	// Supports is whether the metric can be computed over an alignment with the given letters
+	Supports(letters []byte) bool
+	// Compute returns one value per site of the alignment
+	Compute(aln *nexus.Alignment, letters []byte) []float64
+}
+
+// registry holds every known SitewiseMetric, a Metric is its index
+var registry struct {
+	sync.RWMutex
+	mets []SitewiseMetric
+}
+
+// Register makes a SitewiseMetric available, returning the Metric which identifies it
+// Register panics if a metric with the same name or flag has already been registered
+func Register(sm SitewiseMetric) Metric {
+	registry.Lock()
+	defer registry.Unlock()
+	for _, known := range registry.mets {
+		if strings.EqualFold(known.Name(), sm.Name()) || known.Flag() == sm.Flag() {
+			panic(fmt.Sprintf("metrics: %q (--%s) registered twice", sm.Name(), sm.Flag()))
+		}
+	}
+	registry.mets = append(registry.mets, sm)
+	return Metric(len(registry.mets) - 1)
+}
+
+// Registered is every registered Metric in registration order
+func Registered() []Metric {
+	registry.RLock()
+	defer registry.RUnlock()
+	ms := make([]Metric, len(registry.mets))
+	for i := range ms {
+		ms[i] = Metric(i)
+	}
+	return ms
+}
+
+// Lookup finds a registered Metric by its name or flag (case-insensitive)
+func Lookup(name string) (Metric, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for i, sm := range registry.mets {
+		if strings.EqualFold(sm.Name(), name) || strings.EqualFold(sm.Flag(), name) {
+			return Metric(i), true
+		}
+	}
+	return 0, false
+}
+
+// Get is the SitewiseMetric behind m and whether m is registered
+func Get(m Metric) (SitewiseMetric, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	if m < 0 || int(m) >= len(registry.mets) {
+		return nil, false
+	}
+	return registry.mets[m], true
+}
+
+// Validate checks that m is registered and can be computed over the given letters
+func Validate(m Metric, letters []byte) error {
+	sm, ok := Get(m)
+	switch {
+	case !ok:
+		return fmt.Errorf("metric %d is not registered", m)
+	case !sm.Supports(letters):
+		return fmt.Errorf("metric %s does not support alphabet %q", sm.Name(), letters)
+	}
+	return nil
+}
+
+// Compute calculates the sitewise values of m, returning nil if m is not registered
+func Compute(m Metric, aln *nexus.Alignment, letters []byte) []float64 {
+	sm, ok := Get(m)
+	if !ok {
+		return nil
+	}
+	return sm.Compute(aln, letters)
+}
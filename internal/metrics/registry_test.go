@@ -0,0 +1,98 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+// siteIndex is a lab-specific metric whose value is its site position
+type siteIndex struct{}
+
+func (siteIndex) Name() string                 { return "SiteIndex" }
+func (siteIndex) Flag() string                 { return "siteIndex" }
+func (siteIndex) Usage() string                { return "Site position" }
+func (siteIndex) Supports(letters []byte) bool { return true }
+func (siteIndex) Compute(aln *nexus.Alignment, letters []byte) []float64 {
+	vals := make([]float64, aln.Len())
+	for i := range vals {
+		vals[i] = float64(i)
+	}
+	return vals
+}
+
+func TestRegister(t *testing.T) {
+	m := metrics.Register(siteIndex{})
+	defer metrics.Unregister(m)
+
+	t.Run("String", func(t *testing.T) {
+		if m.String() != "SiteIndex" {
+			t.Errorf("Got: %s, expected: %s", m.String(), "SiteIndex")
+		}
+	})
+	t.Run("Registered", func(t *testing.T) {
+		found := false
+		for _, r := range metrics.Registered() {
+			if r == m {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s missing from registered metrics", m)
+		}
+	})
+	t.Run("Lookup", func(t *testing.T) {
+		for _, name := range []string{"SiteIndex", "siteindex", "siteIndex"} {
+			if got, ok := metrics.Lookup(name); !ok || got != m {
+				t.Errorf("Lookup(%q) got (%v, %t), expected (%v, true)", name, got, ok, m)
+			}
+		}
+		if _, ok := metrics.Lookup("not a metric"); ok {
+			t.Errorf("Lookup of unknown metric should fail")
+		}
+	})
+	t.Run("Compute", func(t *testing.T) {
+		aln := nexus.Alignment([]string{"ATG", "ATG"})
+		got := metrics.Compute(m, &aln, []byte("ATGC"))
+		if len(got) != 3 || got[2] != 2 {
+			t.Errorf("Got %v, expected [0 1 2]", got)
+		}
+	})
+	t.Run("Duplicate", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Registering a metric twice should panic")
+			}
+		}()
+		metrics.Register(siteIndex{})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		met     metrics.Metric
+		letters []byte
+		valid   bool
+	}{
+		{metrics.Entropy, []byte("ATGC"), true},
+		{metrics.Entropy, []byte("ACDEFGHIKLMNPQRSTVWY"), true},
+		{metrics.GC, []byte("ATGC"), true},
+		{metrics.GC, []byte("acgtACGT"), true},
+		{metrics.GC, []byte("AUGC"), true},
+		{metrics.GC, []byte("ACDEFGHIKLMNPQRSTVWY"), false},
+		{metrics.GC, []byte("01"), false},
+		{metrics.Multi, []byte("01"), true},
+		{metrics.Multi, []byte(""), false},
+		{metrics.Metric(-1), []byte("ATGC"), false},
+	}
+	for _, tc := range tt {
+		err := metrics.Validate(tc.met, tc.letters)
+		if tc.valid && err != nil {
+			t.Errorf("Validate(%v, %q) => Got: %v, Expected: nil", tc.met, tc.letters, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Validate(%v, %q) => Got: nil, Expected: !nil", tc.met, tc.letters)
+		}
+	}
+}
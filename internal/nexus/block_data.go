@@ -1,94 +1,243 @@
 package nexus
 
 import (
-	"log"
 	"strconv"
 	"strings"
-
-	"github.com/pkg/errors"
 )
 
+// letters are the known (capital) characters of each DATATYPE
+var letters = map[string][]byte{
+	"DNA":        []byte("ATGC"),
+	"RNA":        []byte("AUGC"),
+	"NUCLEOTIDE": []byte("ATGC"), // U should be treated as synonymous to T in this case
+	"STANDARD":   []byte("01"),
+	"PROTEIN":    []byte("ACDEFGHIKLMNPQRSTVWY"),
+}
+
 type dataBlock struct {
-	ntax      int       // Number of taxa
-	nchar     int       // Number of characters
-	dataType  string    // Data type (e.g. DNA, RNA, Nucleotide, Protein)
-	gap       byte      // Gap element character
-	missing   byte      // Missing element character
-	alignment Alignment // All sequences under consideration
+	ntax       int           // Number of taxa
+	nchar      int           // Number of characters
+	dataType   string        // Data type in upper case (e.g. DNA, RNA, NUCLEOTIDE, PROTEIN)
+	gap        byte          // Gap element character
+	missing    byte          // Missing element character
+	interleave bool          // MATRIX rows are segments of each taxon, in turn
+	matchChar  byte          // Stands for the first taxon's character at the site, zero for none
+	equate     map[rune]rune // EQUATE symbols and the state each stands for
+	labels     []string      // Taxon names declared by a TAXA block, nil for a DATA block
+	taxa       []string      // Taxon names, in MATRIX order
+	alignment  Alignment     // All sequences under consideration
+}
+
+// processDataBlock reads the DATA block's commands and writes to the passed Nexus
+func processDataBlock(cmds []command, nex *Nexus) error {
+	return processCharacters("DATA", new(dataBlock), cmds, nex)
+}
+
+// processCharactersBlock reads the CHARACTERS block's commands and writes to the passed Nexus
+// Its taxa are those of any earlier TAXA block
+func processCharactersBlock(cmds []command, nex *Nexus) error {
+	block := &dataBlock{ntax: nex.taxa.ntax, labels: nex.taxa.labels}
+	if block.ntax == 0 {
+		block.ntax = len(block.labels)
+	}
+	return processCharacters("CHARACTERS", block, cmds, nex)
 }
 
-func processDataBlock(lines []string, nex *Nexus) {
-	block := new(dataBlock)
-	for i := 0; i < len(lines); i++ {
-		fields := strings.Fields(strings.ToUpper(lines[i]))
-		if len(fields) != 0 {
-			switch fields[0] {
-			case "DIMENSIONS":
+// processCharacters reads the commands of a DATA or CHARACTERS block into the block, and writes it to the passed Nexus
+func processCharacters(name string, block *dataBlock, cmds []command, nex *Nexus) error {
+	block.dataType = "STANDARD" // The Nexus default when FORMAT gives no DATATYPE
+	for _, cmd := range cmds {
+		switch cmd.name {
+		case "DIMENSIONS":
+			for _, opt := range options(cmd.args) {
 				var err error
-				for _, word := range fields[1:] {
-					if strings.HasPrefix(word, "NTAX=") {
-						pair := strings.FieldsFunc(word, splitOnChar('='))
-						strnum := strings.Trim(pair[1], " ;")
-						block.ntax, err = strconv.Atoi(strnum)
-						if err != nil {
-							err = errors.Wrap(err, "Could not convert to int")
-							log.Println(err)
-						}
-					}
-					if strings.HasPrefix(word, "NCHAR") {
-						pair := strings.FieldsFunc(word, splitOnChar('='))
-						strnum := strings.Trim(pair[1], " ;")
-						block.nchar, err = strconv.Atoi(strnum)
-						if err != nil {
-							err = errors.Wrap(err, "Could not convert to int")
-							log.Println(err)
-						}
-					}
+				switch opt.key {
+				case "NTAX":
+					block.ntax, err = strconv.Atoi(opt.value)
+				case "NCHAR":
+					block.nchar, err = strconv.Atoi(opt.value)
 				}
-			case "FORMAT":
-				for _, word := range fields[1:] {
-					if strings.HasPrefix(word, "DATATYPE=") {
-						pair := strings.FieldsFunc(word, splitOnChar('='))
-						block.dataType = strings.Trim(pair[1], " ;")
-					}
-					if strings.HasPrefix(word, "GAP=") {
-						pair := strings.FieldsFunc(word, splitOnChar('='))
-						block.gap = strings.Trim(pair[1], " ;")[0]
-					}
-					if strings.HasPrefix(word, "MISSING=") {
-						pair := strings.FieldsFunc(word, splitOnChar('='))
-						block.missing = strings.Trim(pair[1], " ;")[0]
-					}
+				if err != nil || block.ntax < 0 || block.nchar < 0 {
+					return errorf(opt.line, "%s must be a whole number, got %q", opt.key, opt.value)
 				}
-			case "MATRIX":
-				for j := i + 1; j < len(lines); j++ {
-					if fields := strings.Fields(lines[j]); len(fields) == 2 {
-						block.alignment = append(block.alignment,
-							fields[1],
-						)
-					} else if strings.Contains(lines[j], ";") {
-						i = j
-						break
+			}
+		case "FORMAT":
+			var equate option
+			for _, opt := range options(cmd.args) {
+				switch {
+				case opt.key == "DATATYPE":
+					block.dataType = strings.ToUpper(opt.value)
+					if _, ok := letters[block.dataType]; !ok {
+						return errorf(opt.line, "unknown DATATYPE %q", opt.value)
 					}
+				case opt.key == "GAP" && opt.value != "":
+					block.gap = opt.value[0]
+				case opt.key == "MISSING" && opt.value != "":
+					block.missing = opt.value[0]
+				case opt.key == "MATCHCHAR" && opt.value != "":
+					block.matchChar = strings.ToUpper(opt.value)[0]
+				case opt.key == "EQUATE":
+					equate = opt
+				case opt.key == "INTERLEAVE": // Bare, or INTERLEAVE=YES/NO
+					block.interleave = opt.value == "" || strings.EqualFold(opt.value, "YES")
 				}
-			default:
-				log.Printf("DATA block processor ignored line:\n%q\n", lines[i])
+			}
+			if equate.key != "" { // Read last, as ambiguous states are read as MISSING
+				var err error
+				if block.equate, err = equates(equate, block.missing); err != nil {
+					return err
+				}
+			}
+		case "MATRIX":
+			if block.interleave {
+				block.readInterleaved(cmd.args)
+			} else {
+				block.readMatrix(cmd.args)
+			}
+			if err := block.checkLengths(cmd.line); err != nil {
+				return err
+			}
+			if err := block.expand(cmd.line); err != nil {
+				return err
+			}
+		default:
+			if err := nex.unknown(cmd, "in "+name+" block"); err != nil {
+				return err
 			}
 		}
 	}
-	block.makeAlignEqual()
 	nex.data = block
-	return
+	return nil
 }
 
-// makeAlignEqual inflates any shorter alignment sequences with the missing character
-func (d *dataBlock) makeAlignEqual() {
-	length := d.nchar
+// readMatrix reads the rows of a MATRIX, each a taxon name then its characters
+// A taxon's characters may continue over several words and lines until NCHAR are read,
+// without NCHAR they end with the line
+func (d *dataBlock) readMatrix(args []token) {
+	for i := 0; i < len(args); {
+		name := args[i]
+		var seq strings.Builder
+		for i++; i < len(args); i++ {
+			if (d.nchar == 0 && args[i].line != name.line) || (0 < d.nchar && d.nchar <= seq.Len()) {
+				break
+			}
+			seq.WriteString(strings.ToUpper(args[i].text))
+		}
+		d.taxa = append(d.taxa, name.text)
+		d.alignment = append(d.alignment, seq.String())
+	}
+}
+
+// readInterleaved reads the rows of an interleaved MATRIX, each line a taxon name then the next segment of its characters
+// Segments accumulate per taxon name, with taxa in order of first appearance
+func (d *dataBlock) readInterleaved(args []token) {
+	index := make(map[string]int)
+	for i := 0; i < len(args); {
+		name := args[i]
+		var seg strings.Builder
+		for i++; i < len(args) && args[i].line == name.line; i++ {
+			seg.WriteString(strings.ToUpper(args[i].text))
+		}
+		j, ok := index[name.text]
+		if !ok {
+			j = len(d.alignment)
+			index[name.text] = j
+			d.taxa = append(d.taxa, name.text)
+			d.alignment = append(d.alignment, "")
+		}
+		d.alignment[j] += seg.String()
+	}
+}
+
+// checkLengths checks the MATRIX, starting at line, has NTAX uniquely named (and declared) taxa each of NCHAR characters (when given)
+func (d *dataBlock) checkLengths(line int) error {
+	if 0 < d.ntax && len(d.alignment) != d.ntax {
+		return errorf(line, "MATRIX has %d taxa, expected NTAX=%d", len(d.alignment), d.ntax)
+	}
+	declared := make(map[string]bool, len(d.labels))
+	for _, name := range d.labels {
+		declared[name] = true
+	}
+	seen := make(map[string]bool, len(d.taxa))
 	for i, v := range d.alignment {
-		if len(v) < length {
-			d.alignment[i] = d.alignment[i] +
-				strings.Repeat(string(d.missing), len(v)-length)
+		if seen[d.taxa[i]] {
+			return errorf(line, "taxon %s appears more than once", d.taxa[i])
+		}
+		if d.labels != nil && !declared[d.taxa[i]] {
+			return errorf(line, "taxon %s is not in TAXLABELS", d.taxa[i])
+		}
+		seen[d.taxa[i]] = true
+		if 0 < d.nchar && len(v) != d.nchar {
+			return errorf(line, "taxon %s has %d characters, expected NCHAR=%d", d.taxa[i], len(v), d.nchar)
+		}
+	}
+	return nil
+}
+
+// expand replaces MATCHCHAR with the first taxon's character at the site, then EQUATE symbols with their states
+// for the MATRIX starting at line
+func (d *dataBlock) expand(line int) error {
+	if d.matchChar != 0 && 0 < len(d.alignment) {
+		first := d.alignment[0]
+		if strings.IndexByte(first, d.matchChar) != -1 {
+			return errorf(line, "first taxon %s uses MATCHCHAR %q", d.taxa[0], d.matchChar)
+		}
+		for i := 1; i < len(d.alignment); i++ {
+			seq := []byte(d.alignment[i])
+			if len(seq) != len(first) { // Without NCHAR, lengths are otherwise unchecked
+				return errorf(line, "taxon %s has %d characters, expected %d as the first taxon MATCHCHAR copies",
+					d.taxa[i], len(seq), len(first))
+			}
+			for j := range seq {
+				if seq[j] == d.matchChar {
+					seq[j] = first[j]
+				}
+			}
+			d.alignment[i] = string(seq)
+		}
+	}
+	if d.equate == nil {
+		return nil
+	}
+	for i, seq := range d.alignment {
+		d.alignment[i] = strings.Map(func(r rune) rune {
+			if state, ok := d.equate[r]; ok {
+				return state
+			}
+			return r
+		}, seq)
+	}
+	return nil
+}
+
+// equates reads EQUATE's "symbol=expansion ..." pairs
+// An expansion to several states, (AG) or {AG}, is ambiguous so is read as the missing character
+func equates(opt option, missing byte) (map[rune]rune, error) {
+	if missing == 0 {
+		missing = '?'
+	}
+	fields := strings.Fields(strings.Replace(strings.ToUpper(opt.value), "=", " = ", -1))
+	equate := make(map[rune]rune)
+	for i := 0; i < len(fields); {
+		if len(fields[i]) != 1 || len(fields) <= i+2 || fields[i+1] != "=" {
+			return nil, errorf(opt.line, "EQUATE must be symbol=expansion pairs, got %q", opt.value)
+		}
+		symbol, states := rune(fields[i][0]), fields[i+2]
+		i += 3
+		// A set of states may hold spaces, (A G)
+		for strings.ContainsAny(states[:1], "({") && !strings.ContainsAny(states, ")}") && i < len(fields) {
+			states += fields[i]
+			i++
+		}
+		states = strings.Trim(states, "(){}")
+		switch len(states) {
+		case 0:
+			return nil, errorf(opt.line, "EQUATE symbol %c has no states", symbol)
+		case 1:
+			equate[symbol] = rune(states[0])
+		default:
+			equate[symbol] = rune(missing)
 		}
 	}
-	return
+	return equate, nil
 }
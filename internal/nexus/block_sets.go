@@ -1,8 +1,6 @@
 package nexus
 
 import (
-	"log"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -12,62 +10,141 @@ import (
 type setsBlock struct {
 	charSets      map[string][]Pair            // Map from charset-name -> []pair
 	charPartition map[string]map[string]string // Map partition-name -> subset-name -> charset-set||charset-name
+	duplicates    []string                     // Charset names defined more than once, once per repeat
+	names         map[string]string            // Map from upper case charset-name -> charset-name, names ignore case
 }
 
-// processSetsBlock parses the SETS block' lines and writes to the passed Nexus
-func processSetsBlock(lines []string, nex *Nexus) {
-	block := new(setsBlock)
-	for i := 0; i < len(lines); i++ {
-		fields := strings.Fields(strings.ToUpper(lines[i]))
-		if len(fields) != 0 {
-			switch fields[0] {
-			case "CHARSET":
-				if len(block.charSets) == 0 {
-					block.charSets = make(map[string][]Pair, 0)
-				}
-				charsetName := fields[1]
-				for _, field := range fields[3:] {
-					setVal := strings.TrimRight(field, ";")
-					if strings.Contains(setVal, "-") {
-						split := strings.Split(setVal, "-")
-						start, _ := strconv.Atoi(split[0])
-						stop, _ := strconv.Atoi(split[1])
-						block.charSets[charsetName] = append(
-							block.charSets[charsetName],
-							NewPair(start, stop+1), // Make stop exclusive so +1 is not needed throughout codebase
-						)
+// processSetsBlock reads the SETS block's commands and writes to the passed Nexus
+// Character sets accumulate across SETS and ASSUMPTIONS blocks
+func processSetsBlock(cmds []command, nex *Nexus) error {
+	block := nex.sets
+	if block.charSets == nil {
+		block.charSets = make(map[string][]Pair)
+		block.charPartition = make(map[string]map[string]string)
+		block.names = make(map[string]string)
+	}
+	for _, cmd := range cmds {
+		switch cmd.name {
+		case "CHARSET":
+			name, members, ok := definition(cmd)
+			if !ok {
+				return errorf(cmd.line, "CHARSET must be name = sites")
+			}
+			if prev, ok := block.names[strings.ToUpper(name)]; ok {
+				block.duplicates = append(block.duplicates, prev)
+				name = prev
+			}
+			pairs, err := block.ranges(members, nex.NChar())
+			if err != nil {
+				return err
+			}
+			block.names[strings.ToUpper(name)] = name
+			block.charSets[name] = append(block.charSets[name], pairs...)
+		case "CHARPARTITION":
+			name, members, ok := definition(cmd)
+			if !ok {
+				return errorf(cmd.line, "CHARPARTITION must be name = subset:members, ...")
+			}
+			block.charPartition[name] = subsets(members)
+		default:
+			if err := nex.unknown(cmd, "in SETS block"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// definition splits a set definition, [*] name [(options)] = members, into its name and members
+func definition(cmd command) (name string, members []token, ok bool) {
+	args := cmd.args
+	if 0 < len(args) && args[0].is("*") {
+		args = args[1:]
+	}
+	for i, t := range args {
+		if t.is("=") {
+			if i == 0 {
+				return "", nil, false
+			}
+			return args[0].text, args[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// ranges converts charset members to Pairs: ranges (1-376 or 1 - 376), single sites, ranges with a step (1-376\3),
+// "." for the last character, and the names of earlier charsets
+func (block *setsBlock) ranges(members []token, nchar int) ([]Pair, error) {
+	// Ranges and steps are split from their sites, as tokens keep - and \ within words
+	var parts []token
+	for _, t := range members {
+		if t.quoted {
+			parts = append(parts, t)
+			continue
+		}
+		for _, f := range strings.FieldsFunc(strings.NewReplacer("-", " - ", `\`, ` \ `).Replace(t.text), func(r rune) bool { return r == ' ' }) {
+			parts = append(parts, token{text: f, line: t.line})
+		}
+	}
+	site := func(t token) (int, bool) {
+		if t.is(".") {
+			return nchar, 0 < nchar
+		}
+		n, err := strconv.Atoi(t.text)
+		return n, err == nil && !t.quoted
+	}
 
-					} else if matched, err := regexp.MatchString(`[0-9]+`, setVal); matched && err == nil {
-						val, _ := strconv.Atoi(setVal)
-						block.charSets[charsetName] = append(
-							block.charSets[charsetName],
-							NewPair(val, val),
-						)
-					}
-				}
-			case "CHARPARTITION":
-				block.charPartition = make(map[string]map[string]string, 0)
-				partitionName := fields[1]
-				for _, field := range fields {
-					entry := strings.TrimRight(field, ";")
-					if strings.Contains(entry, ":") {
-						split := strings.Split(entry, ":")
-						subsetName := split[0]
-						charSet := split[len(split)-1]
-						if _, ok := block.charPartition[partitionName][subsetName]; ok {
-							block.charPartition[partitionName][subsetName] = charSet
-						} else {
-							block.charPartition[partitionName] = make(map[string]string, 0)
-							block.charPartition[partitionName][subsetName] = charSet
-						}
+	var pairs []Pair
+	for i := 0; i < len(parts); i++ {
+		if name, ok := block.names[strings.ToUpper(parts[i].text)]; ok {
+			pairs = append(pairs, block.charSets[name]...)
+			continue
+		}
+		start, ok := site(parts[i])
+		if !ok {
+			return nil, errorf(parts[i].line, "%q is neither a site nor a character set", parts[i].text)
+		}
+		stop, step := start, 1
+		if i+2 < len(parts) && parts[i+1].is("-") {
+			if stop, ok = site(parts[i+2]); !ok {
+				return nil, errorf(parts[i].line, "range %s-%s does not end at a site", parts[i].text, parts[i+2].text)
+			}
+			i += 2
+		}
+		if i+2 < len(parts) && parts[i+1].is(`\`) {
+			n, err := strconv.Atoi(parts[i+2].text)
+			if err != nil || n < 1 {
+				return nil, errorf(parts[i].line, "step %q must be a positive whole number", parts[i+2].text)
+			}
+			step = n
+			i += 2
+		}
+		if step == 1 {
+			pairs = append(pairs, NewPair(start, stop+1)) // Make stop exclusive so +1 is not needed throughout codebase
+			continue
+		}
+		for s := start; s <= stop; s += step {
+			pairs = append(pairs, NewPair(s, s+1)) // A single site, stop exclusive
+		}
+	}
+	return pairs, nil
+}
 
-					}
-				}
-			default:
-				log.Printf("SET block processor ignored line:\n%q\n", lines[i])
+// subsets reads the members of a CHARPARTITION, subset:members separated by commas
+func subsets(members []token) map[string]string {
+	subs := make(map[string]string)
+	for i := 0; i < len(members); {
+		var group []string
+		for ; i < len(members) && !members[i].is(","); i++ {
+			group = append(group, members[i].text)
+		}
+		i++ // Past the comma
+		for j, t := range group {
+			if t == ":" && 0 < j {
+				subs[strings.Join(group[:j], " ")] = strings.Join(group[j+1:], " ")
+				break
 			}
 		}
 	}
-	nex.sets = block
-	return
+	return subs
 }
@@ -0,0 +1,46 @@
+package nexus
+
+import "strconv"
+
+// taxaBlock stores the taxa declared by a TAXA block, for a later CHARACTERS block
+type taxaBlock struct {
+	ntax   int      // Number of taxa
+	labels []string // Taxon names, in order
+}
+
+// processTaxaBlock reads the TAXA block's commands and writes to the passed Nexus
+func processTaxaBlock(cmds []command, nex *Nexus) error {
+	block := new(taxaBlock)
+	for _, cmd := range cmds {
+		switch cmd.name {
+		case "DIMENSIONS":
+			for _, opt := range options(cmd.args) {
+				if opt.key == "NTAX" {
+					n, err := strconv.Atoi(opt.value)
+					if err != nil || n < 0 {
+						return errorf(opt.line, "NTAX must be a whole number, got %q", opt.value)
+					}
+					block.ntax = n
+				}
+			}
+		case "TAXLABELS":
+			seen := make(map[string]bool, len(cmd.args))
+			for _, t := range cmd.args {
+				if seen[t.text] {
+					return errorf(t.line, "taxon %s appears more than once in TAXLABELS", t.text)
+				}
+				seen[t.text] = true
+				block.labels = append(block.labels, t.text)
+			}
+			if 0 < block.ntax && len(block.labels) != block.ntax {
+				return errorf(cmd.line, "TAXLABELS has %d taxa, expected NTAX=%d", len(block.labels), block.ntax)
+			}
+		default:
+			if err := nex.unknown(cmd, "in TAXA block"); err != nil {
+				return err
+			}
+		}
+	}
+	nex.taxa = block
+	return nil
+}
@@ -0,0 +1,18 @@
+package nexus
+
+import "fmt"
+
+// ParseError is a problem reading a Nexus file, at the line it occurs
+type ParseError struct {
+	Line int // Line of the problem, 1-based
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// errorf is a ParseError at line
+func errorf(line int, format string, a ...interface{}) error {
+	return &ParseError{Line: line, Msg: fmt.Sprintf(format, a...)}
+}
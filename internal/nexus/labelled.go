@@ -0,0 +1,80 @@
+package nexus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Labelled is an Alignment with the name of each of its taxa, in order
+type Labelled struct {
+	Taxa []string
+	Alignment
+}
+
+// NewLabelled pairs taxon names with their sequences, checking names are unique and sequences are of equal length
+func NewLabelled(taxa []string, aln Alignment) (Labelled, error) {
+	if len(taxa) != len(aln) {
+		return Labelled{}, fmt.Errorf("%d taxon names for %d sequences", len(taxa), len(aln))
+	}
+	seen := make(map[string]bool, len(taxa))
+	for i, name := range taxa {
+		if seen[name] {
+			return Labelled{}, fmt.Errorf("taxon %s appears more than once", name)
+		}
+		seen[name] = true
+		if len(aln[i]) != len(aln[0]) {
+			return Labelled{}, fmt.Errorf("taxon %s has %d characters, taxon %s has %d", name, len(aln[i]), taxa[0], len(aln[0]))
+		}
+	}
+	return Labelled{Taxa: taxa, Alignment: aln}, nil
+}
+
+// Exclude is the Labelled without the named taxa, every name must be a taxon
+func (l Labelled) Exclude(names []string) (Labelled, error) {
+	drop, err := l.lookup(names)
+	if err != nil {
+		return Labelled{}, err
+	}
+	return l.keep(func(name string) bool { return !drop[name] }), nil
+}
+
+// Include is the Labelled with only the named taxa, in their original order, every name must be a taxon
+func (l Labelled) Include(names []string) (Labelled, error) {
+	kept, err := l.lookup(names)
+	if err != nil {
+		return Labelled{}, err
+	}
+	return l.keep(func(name string) bool { return kept[name] }), nil
+}
+
+// lookup is the set of names, all of which must be taxa, unknown names are reported together
+func (l Labelled) lookup(names []string) (map[string]bool, error) {
+	taxa := make(map[string]bool, len(l.Taxa))
+	for _, name := range l.Taxa {
+		taxa[name] = true
+	}
+	set := make(map[string]bool, len(names))
+	var unknown []string
+	for _, name := range names {
+		if !taxa[name] {
+			unknown = append(unknown, name)
+		}
+		set[name] = true
+	}
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("unknown taxa: %s", strings.Join(unknown, ", "))
+	}
+	return set, nil
+}
+
+// keep is the Labelled with only the taxa whose names pass
+func (l Labelled) keep(pass func(string) bool) Labelled {
+	var out Labelled
+	for i, name := range l.Taxa {
+		if pass(name) {
+			out.Taxa = append(out.Taxa, name)
+			out.Alignment = append(out.Alignment, l.Alignment[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package nexus_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+func TestNewLabelled(t *testing.T) {
+	tt := []struct {
+		name  string
+		taxa  []string
+		aln   nexus.Alignment
+		valid bool
+	}{
+		{"valid", []string{"a", "b"}, nexus.Alignment{"ACGT", "ACGA"}, true},
+		{"missing name", []string{"a"}, nexus.Alignment{"ACGT", "ACGA"}, false},
+		{"repeated name", []string{"a", "a"}, nexus.Alignment{"ACGT", "ACGA"}, false},
+		{"unequal lengths", []string{"a", "b"}, nexus.Alignment{"ACGT", "ACG"}, false},
+	}
+	for _, tc := range tt {
+		_, err := nexus.NewLabelled(tc.taxa, tc.aln)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: Got error %v, Expected valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestLabelledSelect(t *testing.T) {
+	l, _ := nexus.NewLabelled([]string{"a", "b", "c"}, nexus.Alignment{"AAAA", "CCCC", "GGGG"})
+	tt := []struct {
+		name    string
+		include bool
+		names   []string
+		exp     nexus.Labelled
+		valid   bool
+	}{
+		{"exclude", false, []string{"b"}, nexus.Labelled{Taxa: []string{"a", "c"}, Alignment: nexus.Alignment{"AAAA", "GGGG"}}, true},
+		{"include keeps order", true, []string{"c", "a"}, nexus.Labelled{Taxa: []string{"a", "c"}, Alignment: nexus.Alignment{"AAAA", "GGGG"}}, true},
+		{"exclude unknown", false, []string{"b", "z"}, nexus.Labelled{}, false},
+		{"include unknown", true, []string{"z"}, nexus.Labelled{}, false},
+	}
+	for _, tc := range tt {
+		sel := l.Exclude
+		if tc.include {
+			sel = l.Include
+		}
+		got, err := sel(tc.names)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: Got error %v, Expected valid %v", tc.name, err, tc.valid)
+			continue
+		}
+		if tc.valid && !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tt := []struct {
+		word, exp string
+	}{
+		{"sp1", "sp1"},
+		{"genus_species", "genus_species"},
+		{"genus species", "'genus species'"},
+		{"taxon's", "'taxon''s'"},
+		{"a;b", "'a;b'"},
+		{"", "''"},
+	}
+	for _, tc := range tt {
+		if got := nexus.Quote(tc.word); got != tc.exp {
+			t.Errorf("Quote(%q): Got %s, Expected %s", tc.word, got, tc.exp)
+		}
+	}
+}
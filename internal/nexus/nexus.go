@@ -1,27 +1,32 @@
 package nexus
 
 import (
-	"bufio"
-	"fmt"
 	"io"
-	"log"
+	"strings"
 )
 
-// Nexus only understands two blocks: DATA and SETS
+// Nexus only understands the blocks of characters and their sets: DATA (or TAXA and CHARACTERS),
+// and SETS (or ASSUMPTIONS, which PAUP* writes character sets to)
 // Note: meant for exclusive use in swsc
 type Nexus struct {
-	handlers map[string]func([]string, *Nexus)
+	handlers map[string]func([]command, *Nexus) error
+	taxa     *taxaBlock
 	data     *dataBlock
 	sets     *setsBlock
+	strict   bool // Reject commands that are not understood, rather than skip them
 }
 
 // New creates a new empty Nexus with registered handlers and deferred block creation
 func New() *Nexus {
 	nex := &Nexus{
-		handlers: map[string]func([]string, *Nexus){
-			"DATA": processDataBlock,
-			"SETS": processSetsBlock,
+		handlers: map[string]func([]command, *Nexus) error{
+			"TAXA":        processTaxaBlock,
+			"CHARACTERS":  processCharactersBlock,
+			"DATA":        processDataBlock,
+			"SETS":        processSetsBlock,
+			"ASSUMPTIONS": processSetsBlock,
 		},
+		taxa: new(taxaBlock),
 		data: new(dataBlock),
 		sets: new(setsBlock),
 	}
@@ -29,30 +34,62 @@ func New() *Nexus {
 }
 
 // Read reads a Nexus file from a reader returning the filled Nexus
-func Read(file io.Reader) *Nexus {
+// Commands that are not understood are skipped, as are blocks other than those understood
+func Read(file io.Reader) (*Nexus, error) {
 	nex := New()
-	nex.FillFrom(file)
-	return nex
+	return nex, nex.FillFrom(file)
 }
 
-// FillFrom fills in the Nexus with data from a file
+// ReadStrict is Read, but rejects commands that are not understood within the blocks understood
+// Other blocks (e.g. TREES, PAUP) are still skipped whole, so files written by other programs can be read
+func ReadStrict(file io.Reader) (*Nexus, error) {
+	nex := New()
+	nex.strict = true
+	return nex, nex.FillFrom(file)
+}
+
+// FillFrom fills in the Nexus with data from a file, stopping at the first ParseError
 // It overwrites existing values, but does not clear all values
-func (nex *Nexus) FillFrom(file io.Reader) {
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		handled := false
-		for k, f := range nex.handlers {
-			if blockByName(scanner.Text(), k) {
-				lines := copyLines(scanner)
-				f(lines, nex)
-				handled = true
+func (nex *Nexus) FillFrom(file io.Reader) error {
+	toks, err := tokenize(file)
+	if err != nil {
+		return err
+	}
+	if len(toks) == 0 || !toks[0].is("#NEXUS") {
+		return errorf(1, "file does not start with #NEXUS")
+	}
+	cmds := commands(toks[1:])
+	for i := 0; i < len(cmds); i++ {
+		if cmds[i].name != "BEGIN" || len(cmds[i].args) == 0 {
+			if err := nex.unknown(cmds[i], "outside a block"); err != nil {
+				return err
 			}
+			continue
+		}
+		name := strings.ToUpper(cmds[i].args[0].text)
+		end := i + 1
+		for end < len(cmds) && cmds[end].name != "END" && cmds[end].name != "ENDBLOCK" {
+			end++
 		}
-		if !handled && scanner.Text() != "" {
-			log.Printf("Scanner ignored line:\n%q\n", scanner.Text())
+		if end == len(cmds) {
+			return errorf(cmds[i].line, "%s block has no END", name)
 		}
+		if f, ok := nex.handlers[name]; ok {
+			if err := f(cmds[i+1:end], nex); err != nil {
+				return err
+			}
+		}
+		i = end
 	}
-	return
+	return nil
+}
+
+// unknown skips a command that is not understood, or rejects it when strict
+func (nex *Nexus) unknown(cmd command, where string) error {
+	if nex.strict {
+		return errorf(cmd.line, "unknown command %s %s", cmd.name, where)
+	}
+	return nil
 }
 
 // NTax is the number of taxa
@@ -65,7 +102,8 @@ func (nex *Nexus) NChar() int {
 	return nex.data.nchar
 }
 
-// DataType is the type of data (e.g. DNA, RNA, Nucleotide, Protein)
+// DataType is the type of data in upper case (e.g. DNA, RNA, NUCLEOTIDE, PROTEIN), as given in any case
+// A DATA or CHARACTERS block without a DATATYPE is STANDARD, the Nexus default
 func (nex *Nexus) DataType() string {
 	return nex.data.dataType
 }
@@ -89,27 +127,28 @@ func (nex *Nexus) Charsets() map[string][]Pair {
 	return copy
 }
 
+// DuplicateCharsets are the names of character sets defined more than once, their ranges are merged in Charsets
+func (nex *Nexus) DuplicateCharsets() []string {
+	return append([]string(nil), nex.sets.duplicates...)
+}
+
+// Taxa are the taxon names, in MATRIX order
+func (nex *Nexus) Taxa() []string {
+	return append([]string(nil), nex.data.taxa...)
+}
+
+// Labelled is the alignment with its taxon names
+func (nex *Nexus) Labelled() Labelled {
+	return Labelled{Taxa: nex.Taxa(), Alignment: nex.Alignment()}
+}
+
 // Alignment returns a copy of the internal alignment
 func (nex *Nexus) Alignment() Alignment {
-	return nex.data.alignment
+	return append(Alignment(nil), nex.data.alignment...)
 }
 
-// Letters is the known (capital) characters allowed by DataType
+// Letters is the known (capital) characters allowed by DataType, nil without a DATA or CHARACTERS block
 // Missing and Gap characters are NOT appended.
 func (nex *Nexus) Letters() []byte {
-	switch nex.DataType() {
-	case "DNA":
-		return []byte("ATGC")
-	case "RNA":
-		return []byte("AUGC")
-	case "Nucleotide": // U should be treated as synonymous to T in this case
-		return []byte("ATGC")
-	case "Standard":
-		return []byte("01")
-	case "Protein":
-		return []byte("ACDEFGHIKLMNPQRSTVWY")
-	default:
-		msg := fmt.Sprintf("Nexus had invalid data type %s", nex.DataType())
-		panic(msg)
-	}
+	return letters[nex.DataType()]
 }
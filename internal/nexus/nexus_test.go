@@ -17,11 +17,16 @@ func TestNexus(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Could not open example input: %s\n", datafile)
 	}
-	nex.FillFrom(in)
+	if err := nex.FillFrom(in); err != nil {
+		t.Fatalf("Could not read example input: %v", err)
+	}
 
 	t.Run("FillFrom matches Read", func(t *testing.T) {
 		rin, _ := os.Open(datafile)
-		rnex := nexus.Read(rin)
+		rnex, err := nexus.Read(rin)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
 
 		if nex.NTax() != rnex.NTax() {
 			t.Errorf("NTax: FillFrom got %v, Read got %v", nex.NTax(), rnex.NTax())
@@ -63,6 +68,14 @@ func TestNexus(t *testing.T) {
 				t.Errorf("DataType() expected %s, Got %s", "DNA", nex.DataType())
 			}
 		})
+		t.Run("Alignment is a copy", func(t *testing.T) {
+			aln := nex.Alignment()
+			first := aln[0]
+			aln[0] = ""
+			if nex.Alignment()[0] != first {
+				t.Errorf("Alignment() shares its slice, changing a copy changed the Nexus")
+			}
+		})
 		t.Run("Gap", func(t *testing.T) {
 			if nex.Gap() != '-' {
 				t.Errorf("Gap() expected %q, Got %q", '-', nex.Gap())
@@ -205,3 +218,160 @@ func validateCharSet(name string, vs []nexus.Pair, f, s int, t *testing.T) {
 		t.Errorf("Charset %q, expected (%d,%d) got (%d,%d)", name, f, s, first, second)
 	}
 }
+
+func TestCharsetRanges(t *testing.T) {
+	in := strings.NewReader(`#NEXUS
+BEGIN DATA;
+DIMENSIONS NTAX=2 NCHAR=10;
+FORMAT DATATYPE=DNA GAP=- MISSING=?;
+MATRIX
+sp1 ACGTACGTAC
+sp2 ACGTACGTAC
+;
+END;
+BEGIN SETS;
+CHARSET one = 1-3 7-9;
+CHARSET two = 4 10;
+END;
+`)
+	nex, err := nexus.Read(in)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	cs := nex.Charsets()
+	exp := map[string][]nexus.Pair{
+		"one": {{1, 4}, {7, 10}},
+		"two": {{4, 5}, {10, 11}}, // Single sites, stop exclusive
+	}
+	if !reflect.DeepEqual(cs, exp) {
+		t.Errorf("Got %v, Expected %v", cs, exp)
+	}
+}
+
+func TestDuplicateCharsets(t *testing.T) {
+	in := strings.NewReader(`#NEXUS
+BEGIN SETS;
+CHARSET one = 1-3;
+CHARSET two = 4-6;
+CHARSET one = 7-9;
+END;
+`)
+	nex, err := nexus.Read(in)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got, exp := nex.DuplicateCharsets(), []string{"one"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Got %v, Expected %v", got, exp)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	const data = "begin data; dimensions ntax=2 nchar=8; format datatype=dna gap=- missing=?;\n"
+	tt := []struct {
+		name string
+		in   string
+		line int
+	}{
+		{"no header", "begin data;\nend;", 1},
+		{"open comment", "#NEXUS\n[never\nclosed", 2},
+		{"open quote", "#NEXUS\nbegin sets;\ncharset 'a = 1;\nend;", 3},
+		{"no end", "#NEXUS\nbegin data;\ndimensions ntax=1 nchar=1;", 2},
+		{"bad NTAX", "#NEXUS\nbegin data;\ndimensions ntax=two nchar=8;\nend;", 3},
+		{"negative NCHAR", "#NEXUS\nbegin data;\ndimensions ntax=2 nchar=-8;\nend;", 3},
+		{"unknown datatype", "#NEXUS\nbegin data;\nformat datatype=morse;\nend;", 3},
+		{"short interleaved taxon", "#NEXUS\n" + data + "format interleave;\nmatrix\nsp1 ACGT\nsp2 ACGT\nsp1 ACGT\nsp2 AC\n;\nend;", 4},
+		{"missing taxon", "#NEXUS\n" + data + "matrix\nsp1 ACGTACGT\n;\nend;", 3},
+		{"unknown charset", "#NEXUS\nbegin sets;\ncharset a = 1-5;\ncharset b = a c;\nend;", 4},
+		{"open range", "#NEXUS\nbegin sets;\ncharset a = 1-x;\nend;", 3},
+		{"bad step", "#NEXUS\nbegin sets;\ncharset a = 1-9\\0;\nend;", 3},
+		{"charset without members", "#NEXUS\nbegin sets;\ncharset a;\nend;", 3},
+		{"too few taxlabels", "#NEXUS\nbegin taxa;\ndimensions ntax=3;\ntaxlabels a b;\nend;", 4},
+		{"repeated taxlabel", "#NEXUS\nbegin taxa;\ntaxlabels a\nb a;\nend;", 4},
+		{"undeclared taxon", "#NEXUS\nbegin taxa;\ntaxlabels a b;\nend;\nbegin characters;\ndimensions nchar=2;\nmatrix\na AC\nc AC\n;\nend;", 7},
+		{"matchchar in first taxon", "#NEXUS\nbegin data;\ndimensions ntax=2 nchar=2;\nformat matchchar=.;\nmatrix\na A.\nb ..\n;\nend;", 5},
+		{"matchchar taxon longer than first", "#NEXUS\nbegin data;\ndimensions ntax=2;\nformat matchchar=.;\nmatrix\na AC\nb ..G\n;\nend;", 5},
+		{"malformed equate", "#NEXUS\nbegin data;\nformat\nequate=\"R (AG)\";\nend;", 4},
+	}
+	for _, tc := range tt {
+		_, err := nexus.Read(strings.NewReader(tc.in))
+		perr, ok := err.(*nexus.ParseError)
+		if !ok {
+			t.Errorf("%s: Got %v, Expected a ParseError", tc.name, err)
+			continue
+		}
+		if perr.Line != tc.line {
+			t.Errorf("%s: Got error on line %d (%v), Expected line %d", tc.name, perr.Line, perr, tc.line)
+		}
+	}
+}
+
+func TestReadStrict(t *testing.T) {
+	in := "#NEXUS\nbegin data;\ndimensions ntax=1 nchar=4;\nformat datatype=dna;\ncharstatelabels 1 a;\nmatrix\nsp1 ACGT\n;\nend;\nbegin trees;\ntree t = (a);\nend;"
+	if _, err := nexus.Read(strings.NewReader(in)); err != nil {
+		t.Errorf("Read: Got %v, Expected unknown commands skipped", err)
+	}
+	_, err := nexus.ReadStrict(strings.NewReader(in))
+	if perr, ok := err.(*nexus.ParseError); !ok || perr.Line != 5 || !strings.Contains(perr.Msg, "CHARSTATELABELS") {
+		t.Errorf("ReadStrict: Got %v, Expected unknown CHARSTATELABELS on line 5", err)
+	}
+}
+
+func TestDataType(t *testing.T) {
+	tt := []struct {
+		dataType string
+		exp      string
+		letters  []byte
+	}{
+		{"dna", "DNA", []byte("ATGC")},
+		{"Nucleotide", "NUCLEOTIDE", []byte("ATGC")},
+		{"protein", "PROTEIN", []byte("ACDEFGHIKLMNPQRSTVWY")},
+		{"RNA", "RNA", []byte("AUGC")},
+		{"Standard", "STANDARD", []byte("01")},
+	}
+	for _, tc := range tt {
+		nex, err := nexus.Read(strings.NewReader("#NEXUS\nbegin data;\nformat datatype=" + tc.dataType + ";\nend;"))
+		if err != nil {
+			t.Errorf("%s: Got %v, Expected no error", tc.dataType, err)
+			continue
+		}
+		if nex.DataType() != tc.exp || !reflect.DeepEqual(nex.Letters(), tc.letters) {
+			t.Errorf("%s: Got %s %s, Expected %s %s", tc.dataType, nex.DataType(), nex.Letters(), tc.exp, tc.letters)
+		}
+	}
+	nex, err := nexus.Read(strings.NewReader("#NEXUS\nbegin data;\nformat gap=-;\nend;"))
+	if err != nil {
+		t.Fatalf("No DATATYPE: Got %v, Expected no error", err)
+	}
+	if nex.DataType() != "STANDARD" || !reflect.DeepEqual(nex.Letters(), []byte("01")) {
+		t.Errorf("No DATATYPE: Got %s %s, Expected STANDARD 01", nex.DataType(), nex.Letters())
+	}
+	if letters := nexus.New().Letters(); letters != nil {
+		t.Errorf("No DATA block: Got letters %s, Expected nil", letters)
+	}
+}
+
+func TestFormatExpansion(t *testing.T) {
+	tt := []struct {
+		name   string
+		format string
+		matrix string
+		exp    nexus.Alignment
+	}{
+		{"matchchar", "matchchar=.", "a ACGT\nb .C.A\n", nexus.Alignment{"ACGT", "ACGA"}},
+		{"letter matchchar", "matchchar=m", "a ACGT\nb mmmA\n", nexus.Alignment{"ACGT", "ACGA"}},
+		{"single state equate", `equate="X=N"`, "a ACGX\nb ACGT\n", nexus.Alignment{"ACGN", "ACGT"}},
+		{"ambiguous equate", `equate="R={AG} Y = (C T)" missing=?`, "a RCGT\nb ACGY\n", nexus.Alignment{"?CGT", "ACG?"}},
+		{"matchchar then equate", `matchchar=. equate="R=(AG)"`, "a RCGT\nb ...A\n", nexus.Alignment{"?CGT", "?CGA"}},
+	}
+	for _, tc := range tt {
+		in := "#NEXUS\nbegin data;\ndimensions ntax=2 nchar=4;\nformat datatype=dna " + tc.format + ";\nmatrix\n" + tc.matrix + ";\nend;"
+		nex, err := nexus.Read(strings.NewReader(in))
+		if err != nil {
+			t.Errorf("%s: Got %v, Expected no error", tc.name, err)
+			continue
+		}
+		if got := nex.Alignment(); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package nexus
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// token is a word or punctuation mark of a Nexus file, with comments removed
+type token struct {
+	text   string
+	line   int  // Line the token starts on, 1-based
+	quoted bool // Given as a 'quoted word', so never punctuation or a keyword
+}
+
+// punctuation are the characters that are tokens by themselves outside quotes and comments
+// Other Nexus punctuation (e.g. - ? . { }) stays within words, so sequences and ranges are single words
+const punctuation = ";=,:"
+
+// tokenize splits a Nexus file into tokens following Maddison et al. (1997):
+// whitespace separates words, [comments] (which may nest) are removed, and 'quoted words' may hold
+// whitespace and punctuation, with a doubled quote standing for a single quote ("double quotes" likewise)
+func tokenize(r io.Reader) ([]token, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		toks     []token
+		word     []byte
+		wordLine int
+		line     = 1
+	)
+	flush := func() {
+		if len(word) != 0 {
+			toks = append(toks, token{text: string(word), line: wordLine})
+			word = word[:0]
+		}
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\n':
+			flush()
+			line++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			flush()
+		case c == '[':
+			flush()
+			start, depth := line, 1
+			for i++; i < len(src) && 0 < depth; i++ {
+				switch src[i] {
+				case '[':
+					depth++
+				case ']':
+					depth--
+				case '\n':
+					line++
+				}
+			}
+			if 0 < depth {
+				return toks, errorf(start, "comment is never closed")
+			}
+			i-- // Loop increment moves past the closing bracket
+		case c == '\'' || c == '"': // "Double quotes" hold values such as EQUATE's
+			flush()
+			start := line
+			var quoted []byte
+			closed := false
+			for i++; i < len(src); i++ {
+				if src[i] == c {
+					if i+1 < len(src) && src[i+1] == c { // A doubled quote is a literal quote
+						quoted = append(quoted, c)
+						i++
+						continue
+					}
+					closed = true
+					break
+				}
+				if src[i] == '\n' {
+					line++
+				}
+				quoted = append(quoted, src[i])
+			}
+			if !closed {
+				return toks, errorf(start, "quoted word is never closed")
+			}
+			toks = append(toks, token{text: string(quoted), line: start, quoted: true})
+		case strings.IndexByte(punctuation, c) != -1:
+			flush()
+			toks = append(toks, token{text: string(c), line: line})
+		default:
+			if len(word) == 0 {
+				wordLine = line
+			}
+			word = append(word, c)
+		}
+	}
+	flush()
+	return toks, nil
+}
+
+// is checks the token is the unquoted word or punctuation s, ignoring case
+func (t token) is(s string) bool {
+	return !t.quoted && strings.EqualFold(t.text, s)
+}
+
+// command is a Nexus command: its name, in upper case, and its arguments up to the closing semicolon
+type command struct {
+	name string
+	args []token
+	line int
+}
+
+// commands splits tokens into commands at each semicolon, a final command without one is kept
+func commands(toks []token) []command {
+	var cmds []command
+	for i := 0; i < len(toks); {
+		cmd := command{name: strings.ToUpper(toks[i].text), line: toks[i].line}
+		if toks[i].is(";") { // Empty command
+			i++
+			continue
+		}
+		for i++; i < len(toks) && !toks[i].is(";"); i++ {
+			cmd.args = append(cmd.args, toks[i])
+		}
+		i++ // Past the semicolon
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// option is a KEY=value or bare KEY setting of a command, such as those of DIMENSIONS and FORMAT
+type option struct {
+	key   string // Upper case
+	value string // Empty for a bare KEY
+	line  int
+}
+
+// options reads the KEY=value and bare KEY settings of a command
+func options(args []token) []option {
+	var opts []option
+	for i := 0; i < len(args); i++ {
+		opt := option{key: strings.ToUpper(args[i].text), line: args[i].line}
+		if i+2 < len(args) && args[i+1].is("=") {
+			opt.value = args[i+2].text
+			i += 2
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+// Quote is the word as written in a Nexus file, 'quoted' if it holds whitespace, quotes, comments or punctuation
+func Quote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\r\n\f\v'\"[]"+punctuation) {
+		return word
+	}
+	return "'" + strings.Replace(word, "'", "''", -1) + "'"
+}
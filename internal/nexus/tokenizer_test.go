@@ -0,0 +1,114 @@
+package nexus_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+// Files as phyluce, IQ-TREE, PAUP*, MrBayes and Mesquite write them, sequential and interleaved, all holding the same alignment
+var corpus = []struct {
+	file     string
+	taxa     []string
+	charsets map[string][]nexus.Pair
+}{
+	{"phyluce.nex", underscored, map[string][]nexus.Pair{
+		"uce-1000.nexus": {{1, 9}},
+		"uce-1001.nexus": {{9, 21}},
+	}},
+	{"iqtree.nex", underscored, map[string][]nexus.Pair{
+		"part1": {{1, 9}},
+		"part2": {{9, 10}, {12, 13}, {15, 16}, {18, 19}, {10, 11}, {13, 14}, {16, 17}, {19, 20}, {11, 12}, {14, 15}, {17, 18}, {20, 21}},
+	}},
+	{"paup.nex", []string{"genus species1", "genus species2", "genus species3"}, map[string][]nexus.Pair{
+		"first": {{1, 9}},
+		"last":  {{9, 21}},
+	}},
+	{"mrbayes.nex", underscored, map[string][]nexus.Pair{
+		"first": {{1, 9}},
+		"last":  {{9, 21}},
+	}},
+	{"phyluce_interleaved.nex", underscored, map[string][]nexus.Pair{
+		"uce-1000.nexus": {{1, 9}},
+		"uce-1001.nexus": {{9, 21}},
+	}},
+	{"mesquite.nex", underscored, map[string][]nexus.Pair{
+		"first": {{1, 9}},
+		"last":  {{9, 21}},
+	}},
+}
+
+// underscored are the taxon names of the corpus when written without quotes
+var underscored = []string{"genus_species1", "genus_species2", "genus_species3"}
+
+func TestCorpus(t *testing.T) {
+	exp := nexus.Alignment{
+		"ACGTACGTAC-TACGTAC??",
+		"ACGTACGTACGTACGTACGT",
+		"ACGTTCGTACGTACGTAAAA",
+	}
+	for _, tc := range corpus {
+		in, err := os.Open("./testdata/" + tc.file)
+		if err != nil {
+			t.Fatalf("Could not open %s", tc.file)
+		}
+		nex, err := nexus.Read(in)
+		in.Close()
+		if err != nil {
+			t.Errorf("%s: Got %v, Expected no error", tc.file, err)
+			continue
+		}
+		if nex.NTax() != 3 || nex.NChar() != 20 {
+			t.Errorf("%s: Got NTax %d and NChar %d, Expected 3 and 20", tc.file, nex.NTax(), nex.NChar())
+		}
+		if nex.DataType() != "DNA" || nex.Gap() != '-' || nex.Missing() != '?' {
+			t.Errorf("%s: Got format %s %q %q, Expected DNA '-' '?'", tc.file, nex.DataType(), nex.Gap(), nex.Missing())
+		}
+		if got := nex.Alignment(); !reflect.DeepEqual(got, exp) {
+			t.Errorf("%s: Got alignment\n%v\nExpected\n%v", tc.file, got, exp)
+		}
+		if got := nex.Taxa(); !reflect.DeepEqual(got, tc.taxa) {
+			t.Errorf("%s: Got taxa %q, Expected %q", tc.file, got, tc.taxa)
+		}
+		if got := nex.Charsets(); !reflect.DeepEqual(got, tc.charsets) {
+			t.Errorf("%s: Got charsets %v, Expected %v", tc.file, got, tc.charsets)
+		}
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tt := []struct {
+		name     string
+		in       string
+		charsets map[string][]nexus.Pair
+	}{
+		{"nested comment", "begin sets; [a [nested] comment;] charset a = 1-2; end;",
+			map[string][]nexus.Pair{"a": {{1, 3}}}},
+		{"comment within a command", "begin sets; charset a [first] = 1-2 [then] 5; end;",
+			map[string][]nexus.Pair{"a": {{1, 3}, {5, 6}}}},
+		{"quoted name with punctuation", "begin sets; charset 'a; b = c' = 1-2; end;",
+			map[string][]nexus.Pair{"a; b = c": {{1, 3}}}},
+		{"escaped quote", "begin sets; charset 'taxon''s set' = 3; end;",
+			map[string][]nexus.Pair{"taxon's set": {{3, 4}}}},
+		{"multi-line command", "BEGIN SETS;\nCHARSET\na\n=\n1\n-\n2\n;\nEND;",
+			map[string][]nexus.Pair{"a": {{1, 3}}}},
+		{"charset of charsets", "begin sets; charset a = 1-2; charset B = 4; charset c = A b; end;",
+			map[string][]nexus.Pair{"a": {{1, 3}}, "B": {{4, 5}}, "c": {{1, 3}, {4, 5}}}},
+		{"unknown block skipped", "begin trees; tree t = (a,b); end; begin sets; charset a = 1; endblock;",
+			map[string][]nexus.Pair{"a": {{1, 2}}}},
+	}
+	for _, tc := range tt {
+		nex, err := nexus.Read(strings.NewReader("#NEXUS\n" + tc.in))
+		if err != nil {
+			t.Errorf("%s: Got %v, Expected no error", tc.name, err)
+			continue
+		}
+		got := nex.Charsets()
+		if !reflect.DeepEqual(got, tc.charsets) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got, tc.charsets)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package pfinder
 
 import (
 	"fmt"
+	"strings"
 )
 
 // StartBlock writes PartitionFinder2 configuration header/start block
@@ -23,13 +24,33 @@ func StartBlock(datasetName string) string {
 	return block
 }
 
+// Map converts a 1-based inclusive range of a UCE's sites, taken in order, to the 1-based inclusive
+// alignment ranges they cover, so a UCE of disjoint ranges lists each range
+// A nil Map leaves ranges as they are
+type Map func(first, last int) [][2]int
+
 // ConfigBlock appends the proper window size for the UCE
 // If their are either undetermined or blocks w/o all sites the fullRange should be used
+// A window without a left or right flank splits the UCE into a left and right block
 func ConfigBlock(name string, bestWindow [2]int, start, stop int, fullRange bool) string {
+	return Map(nil).ConfigBlock(name, bestWindow, start, stop, fullRange)
+}
+
+// ConfigBlock is ConfigBlock with positions counted along the UCE's sites and mapped to the alignment
+func (m Map) ConfigBlock(name string, bestWindow [2]int, start, stop int, fullRange bool) string {
 	block := ""
-	if fullRange || bestWindow[1]-bestWindow[0] == stop-start {
-		block = fmt.Sprintf("%s_all = %d-%d;\n", name, start, stop)
-	} else {
+	noLeft := bestWindow[0] <= start
+	noRight := stop <= bestWindow[1]
+	switch {
+	case fullRange || (noLeft && noRight):
+		block = m.line(name, "all", start, stop)
+	case noLeft: // core is the left block
+		block = m.line(name, "left", start, bestWindow[1]) +
+			m.line(name, "right", bestWindow[1]+1, stop)
+	case noRight: // core is the right block
+		block = m.line(name, "left", start, bestWindow[0]-1) +
+			m.line(name, "right", bestWindow[0], stop)
+	default:
 		// left UCE
 		leftStart := start
 		leftEnd := bestWindow[0] - 1
@@ -39,14 +60,47 @@ func ConfigBlock(name string, bestWindow [2]int, start, stop int, fullRange bool
 		// right UCE
 		rightStart := coreEnd + 1
 		rightEnd := stop
-		block = fmt.Sprintf("%s_left = %d-%d;\n", name, leftStart, leftEnd) +
-			fmt.Sprintf("%s_core = %d-%d;\n", name, coreStart, coreEnd) +
-			fmt.Sprintf("%s_right = %d-%d;\n", name, rightStart, rightEnd)
+		block = m.line(name, "left", leftStart, leftEnd) +
+			m.line(name, "core", coreStart, coreEnd) +
+			m.line(name, "right", rightStart, rightEnd)
 	}
 
 	return block
 }
 
+// SegmentBlock appends one block per segment of the UCE, named <name>_seg1 to <name>_segk
+// segments are 1-based and inclusive. If any segment is undetermined or lacks sites the fullRange should be used
+func SegmentBlock(name string, segments [][2]int, fullRange bool) string {
+	return Map(nil).SegmentBlock(name, segments, fullRange)
+}
+
+// SegmentBlock is SegmentBlock with positions counted along the UCE's sites and mapped to the alignment
+func (m Map) SegmentBlock(name string, segments [][2]int, fullRange bool) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	if fullRange || len(segments) == 1 {
+		return m.line(name, "all", segments[0][0], segments[len(segments)-1][1])
+	}
+	block := ""
+	for i, seg := range segments {
+		block += m.line(name, fmt.Sprintf("seg%d", i+1), seg[0], seg[1])
+	}
+	return block
+}
+
+// line is a single block, <name>_<suffix>, covering the 1-based inclusive range [first, last]
+func (m Map) line(name, suffix string, first, last int) string {
+	if m == nil {
+		return fmt.Sprintf("%s_%s = %d-%d;\n", name, suffix, first, last)
+	}
+	var ranges []string
+	for _, r := range m(first, last) {
+		ranges = append(ranges, fmt.Sprintf("%d-%d", r[0], r[1]))
+	}
+	return fmt.Sprintf("%s_%s = %s;\n", name, suffix, strings.Join(ranges, " "))
+}
+
 // EndBlock appends the end block to the specified .cfg file
 func EndBlock() string {
 	search := "rclusterf"
@@ -52,25 +52,53 @@ func TestConfigBlock(t *testing.T) {
 		bestWindow  [2]int
 		start, stop int
 		fullRange   bool
+		nLines      int
 	}{
-		{"UCE01-partial", [2]int{10, 60}, 5, 100, false},
-		{"UCE01-full", [2]int{10, 60}, 5, 100, true},
+		{"UCE01-partial", [2]int{10, 60}, 5, 100, false, 3},   // left, core, right flank
+		{"UCE01-full", [2]int{10, 60}, 5, 100, true, 1},       // full range
+		{"UCE01-unsplit", [2]int{5, 100}, 5, 100, false, 1},   // window is the full range
+		{"UCE01-no-right", [2]int{10, 100}, 5, 100, false, 2}, // left, right
+		{"UCE01-no-left", [2]int{5, 60}, 5, 100, false, 2},    // left, right
 	}
 	for _, tc := range tt {
 		got := pfinder.ConfigBlock(tc.name, tc.bestWindow, tc.start, tc.stop, tc.fullRange)
 		t.Run("Correct number of lines", func(t *testing.T) {
 			nLines := len(strings.Split(strings.TrimSpace(got), "\n"))
-			if tc.fullRange || tc.bestWindow[1]-tc.bestWindow[0] == tc.stop-tc.start {
-				if nLines != 1 {
-					t.Errorf("Expected 1 output line (full range), got %d", nLines)
-				}
-			} else {
-				if nLines != 3 {
-					t.Errorf("Expected 3 output line (left, core, right flank), got %d", nLines)
-				}
+			if nLines != tc.nLines {
+				t.Errorf("%s: Expected %d output lines, got %d", tc.name, tc.nLines, nLines)
 			}
 		})
 	}
+	t.Run("Two blocks span the UCE", func(t *testing.T) {
+		exp := "UCE01_left = 5-9;\nUCE01_right = 10-100;\n"
+		if got := pfinder.ConfigBlock("UCE01", [2]int{10, 100}, 5, 100, false); got != exp {
+			t.Errorf("Got:\n%s\nExpected:\n%s", got, exp)
+		}
+		exp = "UCE01_left = 5-60;\nUCE01_right = 61-100;\n"
+		if got := pfinder.ConfigBlock("UCE01", [2]int{5, 60}, 5, 100, false); got != exp {
+			t.Errorf("Got:\n%s\nExpected:\n%s", got, exp)
+		}
+	})
+}
+
+func TestSegmentBlock(t *testing.T) {
+	segs := [][2]int{{5, 20}, {21, 60}, {61, 80}, {81, 100}}
+	tt := []struct {
+		name      string
+		segs      [][2]int
+		fullRange bool
+		exp       string
+	}{
+		{"UCE01", segs, false, "UCE01_seg1 = 5-20;\nUCE01_seg2 = 21-60;\nUCE01_seg3 = 61-80;\nUCE01_seg4 = 81-100;\n"},
+		{"UCE01", segs, true, "UCE01_all = 5-100;\n"},
+		{"UCE01", segs[:1], false, "UCE01_all = 5-20;\n"},
+		{"UCE01", nil, false, ""},
+	}
+	for _, tc := range tt {
+		if got := pfinder.SegmentBlock(tc.name, tc.segs, tc.fullRange); got != tc.exp {
+			t.Errorf("Got:\n%s\nExpected:\n%s", got, tc.exp)
+		}
+	}
 }
 
 func TestEndBlock(t *testing.T) {
@@ -92,3 +120,31 @@ func TestEndBlock(t *testing.T) {
 		t.Errorf("Expected block and received block did not match. Differences:\n%s", diff)
 	}
 }
+
+func TestMap(t *testing.T) {
+	// A UCE of alignment sites 5-9 and 20-24, counted along its sites as 1-10
+	sites := []int{5, 6, 7, 8, 9, 20, 21, 22, 23, 24}
+	m := pfinder.Map(func(first, last int) [][2]int {
+		var rs [][2]int
+		for _, s := range sites[first-1 : last] {
+			if 0 < len(rs) && rs[len(rs)-1][1]+1 == s {
+				rs[len(rs)-1][1] = s
+				continue
+			}
+			rs = append(rs, [2]int{s, s})
+		}
+		return rs
+	})
+	exp := "UCE01_left = 5-6;\nUCE01_core = 7-9 20-21;\nUCE01_right = 22-24;\n"
+	if got := m.ConfigBlock("UCE01", [2]int{3, 7}, 1, 10, false); got != exp {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, exp)
+	}
+	exp = "UCE01_all = 5-9 20-24;\n"
+	if got := m.ConfigBlock("UCE01", [2]int{3, 7}, 1, 10, true); got != exp {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, exp)
+	}
+	exp = "UCE01_seg1 = 5-9;\nUCE01_seg2 = 20-24;\n"
+	if got := m.SegmentBlock("UCE01", [][2]int{{1, 5}, {6, 10}}, false); got != exp {
+		t.Errorf("Got:\n%s\nExpected:\n%s", got, exp)
+	}
+}
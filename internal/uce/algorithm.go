@@ -0,0 +1,93 @@
+package uce
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// Algorithm is the method used to partition a UCE
+type Algorithm int
+
+const (
+	// SWSC finds the best left flank, core, and right flank with a Search
+	SWSC Algorithm = iota
+
+	// DP finds the optimal split into consecutive segments by dynamic programming
+	DP
+
+	// PELT finds the optimal penalised split into consecutive segments by pruned exact linear time
+	PELT
+
+	// BinSeg splits into consecutive segments by binary segmentation
+	BinSeg
+
+	// HMM decodes the left flank, core, and right flank of a hidden Markov model
+	HMM
+)
+
+// algorithms are all known Algorithms
+var algorithms = []Algorithm{SWSC, DP, PELT, BinSeg, HMM}
+
+func (a Algorithm) String() string {
+	switch a {
+	case SWSC:
+		return "swsc"
+	case DP:
+		return "dp"
+	case PELT:
+		return "pelt"
+	case BinSeg:
+		return "binseg"
+	case HMM:
+		return "hmm"
+	default:
+		return ""
+	}
+}
+
+// ParseAlgorithm converts the name of an algorithm to its Algorithm
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range algorithms {
+		if strings.EqualFold(name, a.String()) {
+			return a, nil
+		}
+	}
+	names := make([]string, len(algorithms))
+	for i, a := range algorithms {
+		names[i] = a.String()
+	}
+	return 0, fmt.Errorf("unknown algorithm %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// MinLength is the shortest UCE the algorithm can split with the sizes
+// SWSC and HMM need a core and two flanks, segmentation algorithms need two segments
+func (a Algorithm) MinLength(sizes windows.Sizes) int {
+	switch a {
+	case SWSC, HMM:
+		return sizes.MinLength()
+	default:
+		return 2 * sizes.MinBlock()
+	}
+}
+
+// Segment partitions the UCE [start, stop) into segments of at least the shorter of the core and flank for each metric
+// HMM partitions into a left flank, core, and right flank, like SWSC, with the chance each site is in the core
+// SWSC is not a segmentation algorithm and returns nil
+func (a Algorithm) Segment(start, stop int, mets map[metrics.Metric][]float64, opts Options) map[metrics.Metric]windows.Best {
+	sizes := opts.Sizes(stop - start)
+	switch a {
+	case DP:
+		return windows.Segment(mets, start, stop, sizes.MinBlock(), int(opts.Segments), opts.Ranking.Objective, opts.Criterion, opts.Penalty)
+	case PELT:
+		return windows.PELT(mets, start, stop, sizes.MinBlock(), opts.Ranking.Objective, opts.Penalty)
+	case BinSeg:
+		return windows.BinSeg(mets, start, stop, sizes.MinBlock(), int(opts.Segments), opts.Ranking.Objective, opts.Penalty)
+	case HMM:
+		return windows.HMM(mets, start, stop, sizes, opts.Ranking.Objective)
+	default:
+		return nil
+	}
+}
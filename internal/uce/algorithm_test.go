@@ -0,0 +1,70 @@
+package uce_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/uce"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	tt := []struct {
+		name  string
+		exp   uce.Algorithm
+		valid bool
+	}{
+		{"swsc", uce.SWSC, true},
+		{"DP", uce.DP, true},
+		{"pelt", uce.PELT, true},
+		{"binseg", uce.BinSeg, true},
+		{"HMM", uce.HMM, true},
+		{"kmeans", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := uce.ParseAlgorithm(tc.name)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseAlgorithm(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.name, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseAlgorithm(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
+
+func TestSegment(t *testing.T) {
+	mets := map[metrics.Metric][]float64{metrics.GC: make([]float64, 60)}
+	opts := uce.Options{MinWin: 10, Segments: 4}
+	if got := uce.SWSC.Segment(0, 60, mets, opts); got != nil {
+		t.Errorf("swsc: Got %v, Expected nil", got)
+	}
+	for _, a := range []uce.Algorithm{uce.DP, uce.BinSeg} {
+		if got := a.Segment(0, 60, mets, opts)[metrics.GC]; len(got.Segments) != 4 {
+			t.Errorf("%s: Got %d segments, Expected 4", a, len(got.Segments))
+		}
+	}
+	// A constant metric is never worth splitting under a penalty
+	if got := uce.PELT.Segment(0, 60, mets, opts)[metrics.GC]; len(got.Segments) != 1 {
+		t.Errorf("pelt: Got %d segments, Expected 1", len(got.Segments))
+	}
+}
+
+func TestMinLength(t *testing.T) {
+	sizes := windows.Sizes{MinCore: 40, MinFlank: 10}
+	tt := []struct {
+		a   uce.Algorithm
+		exp int
+	}{
+		{uce.SWSC, 60},
+		{uce.HMM, 60},
+		{uce.DP, 20},
+		{uce.PELT, 20},
+		{uce.BinSeg, 20},
+	}
+	for _, tc := range tt {
+		if got := tc.a.MinLength(sizes); got != tc.exp {
+			t.Errorf("%s: Got %d, Expected %d", tc.a, got, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package uce
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultCandidates is the number of candidates used when both minWin and candidates are automatic
+const DefaultCandidates = 3
+
+// Auto marks which Options are chosen from UCE lengths rather than given
+type Auto struct {
+	MinWin     bool
+	Candidates bool
+	PerUce     bool // Tune each UCE to its own length, otherwise all to the shortest UCE
+}
+
+// Any is whether any Options are automatic
+func (a Auto) Any() bool {
+	return a.MinWin || a.Candidates
+}
+
+// Tune chooses the automatic Options for UCEs of at least n sites so minWin*candidates is about a third of n,
+// letting candidates span the full length of the shortest UCE.
+// With both automatic, candidates is DefaultCandidates and minWin is chosen.
+func (a Auto) Tune(opts Options, n int) Options {
+	third := float64(n) / 3
+	switch {
+	case a.MinWin:
+		if a.Candidates {
+			opts.Candidates = DefaultCandidates
+		}
+		candidates := math.Max(float64(opts.Candidates), 1)
+		opts.MinWin = uint(math.Max(math.Floor(third/candidates), 1))
+	case a.Candidates:
+		minWin := math.Max(float64(opts.MinWin), 1)
+		opts.Candidates = uint(math.Max(math.Round(third/minWin), 1))
+	}
+	return opts
+}
+
+// Scope is where automatic Options are tuned, "global" or "uce"
+func (a Auto) Scope() string {
+	if a.PerUce {
+		return "uce"
+	}
+	return "global"
+}
+
+// ParseScope converts where automatic Options are tuned to whether they are tuned per UCE
+func ParseScope(name string) (bool, error) {
+	switch strings.ToLower(name) {
+	case "global":
+		return false, nil
+	case "uce":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown scope %q, expected global or uce", name)
+	}
+}
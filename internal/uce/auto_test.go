@@ -0,0 +1,56 @@
+package uce_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/uce"
+)
+
+func TestTune(t *testing.T) {
+	given := uce.Options{MinWin: 50, Candidates: 3}
+	tt := []struct {
+		name          string
+		auto          uce.Auto
+		n             int
+		minWin, cands uint
+	}{
+		{"none", uce.Auto{}, 900, 50, 3},
+		{"minWin", uce.Auto{MinWin: true}, 900, 100, 3},
+		{"candidates", uce.Auto{Candidates: true}, 900, 50, 6},
+		{"both", uce.Auto{MinWin: true, Candidates: true}, 900, 100, uce.DefaultCandidates},
+		{"rounded candidates", uce.Auto{Candidates: true}, 400, 50, 3},
+		{"short UCE", uce.Auto{MinWin: true}, 5, 1, 3},
+		{"minWin above a third", uce.Auto{Candidates: true}, 90, 50, 1},
+	}
+	for _, tc := range tt {
+		got := tc.auto.Tune(given, tc.n)
+		if got.MinWin != tc.minWin || got.Candidates != tc.cands {
+			t.Errorf("%s: Got minWin %d and candidates %d, Expected %d and %d", tc.name, got.MinWin, got.Candidates, tc.minWin, tc.cands)
+		}
+		// A UCE of n sites must still hold a core and two flanks of minWin
+		if tc.auto.MinWin && tc.n < int(3*got.MinWin) && 1 < got.MinWin {
+			t.Errorf("%s: minWin %d too large for %d sites", tc.name, got.MinWin, tc.n)
+		}
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	tt := []struct {
+		name   string
+		perUce bool
+		valid  bool
+	}{
+		{"global", false, true},
+		{"UCE", true, true},
+		{"locus", false, false},
+	}
+	for _, tc := range tt {
+		got, err := uce.ParseScope(tc.name)
+		if tc.valid && (err != nil || got != tc.perUce) {
+			t.Errorf("ParseScope(%q) => Got: (%t, %v), Expected: (%t, nil)", tc.name, got, err, tc.perUce)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseScope(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
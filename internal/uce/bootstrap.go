@@ -0,0 +1,160 @@
+package uce
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/windows"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Resample is what is drawn with replacement in each bootstrap replicate
+type Resample int
+
+const (
+	// Taxa draws sequences (rows) of the alignment
+	Taxa Resample = 1 << iota
+
+	// Sites draws sites (columns) within each block of the best window, keeping the blocks in place
+	Sites
+
+	// Both draws taxa and sites
+	Both = Taxa | Sites
+)
+
+func (r Resample) String() string {
+	switch r {
+	case Taxa:
+		return "taxa"
+	case Sites:
+		return "sites"
+	case Both:
+		return "both"
+	default:
+		return ""
+	}
+}
+
+// ParseResample converts the name of what to resample to its Resample
+func ParseResample(name string) (Resample, error) {
+	for _, r := range []Resample{Taxa, Sites, Both} {
+		if strings.EqualFold(name, r.String()) {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown resample %q, expected taxa, sites, or both", name)
+}
+
+// Boundaries are the core start and stop chosen in each bootstrap replicate, in alignment positions
+type Boundaries struct {
+	Starts, Stops []int
+}
+
+// Bootstrap reruns the search over n resamples of the UCE [start, stop) for each metric with a best window.
+// Metrics are recomputed over the UCE alone in each replicate.
+// Sites are resampled within the left flank, core, and right flank of the metric's best window.
+func Bootstrap(aln nexus.Alignment, letters []byte, start, stop int, best map[metrics.Metric]windows.Best, s Search, opts Options, r Resample, n uint, rng *rand.Rand) map[metrics.Metric]Boundaries {
+	// Metrics in order so draws from rng are reproducible
+	mets := make([]metrics.Metric, 0, len(best))
+	for m := range best {
+		mets = append(mets, m)
+	}
+	sort.Slice(mets, func(i, j int) bool { return mets[i] < mets[j] })
+
+	uceAln := aln.Subseq(start, stop)
+	bounds := make(map[metrics.Metric]Boundaries, len(mets))
+	for i := uint(0); i < n; i++ {
+		rows := identity(int(uceAln.NSeq()))
+		if r&Taxa != 0 {
+			rows = draw(rng, 0, len(rows))
+		}
+		for _, m := range mets {
+			w := best[m].Window
+			cols := identity(stop - start)
+			if r&Sites != 0 {
+				cols = cols[:0]
+				for _, b := range [][2]int{{start, w.Start()}, {w.Start(), w.Stop()}, {w.Stop(), stop}} {
+					cols = append(cols, draw(rng, b[0]-start, b[1]-start)...)
+				}
+			}
+			rep := resample(uceAln, rows, cols)
+			vals := map[metrics.Metric][]float64{m: metrics.Compute(m, &rep, letters)}
+			got, ok := s.Process(0, stop-start, vals, opts)[m]
+			if !ok {
+				continue
+			}
+			b := bounds[m]
+			b.Starts = append(b.Starts, got.Window.Start()+start)
+			b.Stops = append(b.Stops, got.Window.Stop()+start)
+			bounds[m] = b
+		}
+	}
+	return bounds
+}
+
+// identity is the positions [0, n)
+func identity(n int) []int {
+	is := make([]int, n)
+	for i := range is {
+		is[i] = i
+	}
+	return is
+}
+
+// draw is stop-start positions drawn with replacement from [start, stop)
+func draw(rng *rand.Rand, start, stop int) []int {
+	is := make([]int, stop-start)
+	for i := range is {
+		is[i] = start + rng.Intn(stop-start)
+	}
+	return is
+}
+
+// resample builds an alignment from the given rows and columns of aln
+func resample(aln nexus.Alignment, rows, cols []int) nexus.Alignment {
+	out := make(nexus.Alignment, len(rows))
+	for i, r := range rows {
+		seq := make([]byte, len(cols))
+		for j, c := range cols {
+			seq[j] = aln[r][c]
+		}
+		out[i] = string(seq)
+	}
+	return out
+}
+
+// Frequencies is each distinct position, in order, and the fraction of replicates which chose it
+func Frequencies(positions []int) ([]int, []float64) {
+	counts := make(map[int]int)
+	for _, p := range positions {
+		counts[p]++
+	}
+	sites := make([]int, 0, len(counts))
+	for p := range counts {
+		sites = append(sites, p)
+	}
+	sort.Ints(sites)
+	freqs := make([]float64, len(sites))
+	for i, p := range sites {
+		freqs[i] = float64(counts[p]) / float64(len(positions))
+	}
+	return sites, freqs
+}
+
+// Interval is the percentile interval holding the central level (e.g. 0.95) of positions
+func Interval(positions []int, level float64) (int, int) {
+	if len(positions) == 0 {
+		return 0, 0
+	}
+	x := make([]float64, len(positions))
+	for i, p := range positions {
+		x[i] = float64(p)
+	}
+	sort.Float64s(x)
+	tail := (1 - level) / 2
+	return int(stat.Quantile(tail, stat.Empirical, x, nil)), int(stat.Quantile(1-tail, stat.Empirical, x, nil))
+}
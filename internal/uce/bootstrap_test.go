@@ -0,0 +1,94 @@
+package uce_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/uce"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestParseResample(t *testing.T) {
+	tt := []struct {
+		name  string
+		exp   uce.Resample
+		valid bool
+	}{
+		{"taxa", uce.Taxa, true},
+		{"Sites", uce.Sites, true},
+		{"both", uce.Both, true},
+		{"columns", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := uce.ParseResample(tc.name)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseResample(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.name, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseResample(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
+
+func TestFrequencies(t *testing.T) {
+	sites, freqs := uce.Frequencies([]int{12, 10, 12, 12})
+	if !reflect.DeepEqual(sites, []int{10, 12}) || !reflect.DeepEqual(freqs, []float64{0.25, 0.75}) {
+		t.Errorf("Got: (%v, %v), Expected: ([10 12], [0.25 0.75])", sites, freqs)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	positions := make([]int, 100)
+	for i := range positions {
+		positions[i] = 100 - i // 1..100, out of order
+	}
+	tt := []struct {
+		level  float64
+		lo, hi int
+	}{
+		{0.9, 5, 95},
+		{1, 1, 100},
+	}
+	for _, tc := range tt {
+		lo, hi := uce.Interval(positions, tc.level)
+		if lo != tc.lo || hi != tc.hi {
+			t.Errorf("Level %v => Got: (%d, %d), Expected: (%d, %d)", tc.level, lo, hi, tc.lo, tc.hi)
+		}
+	}
+}
+
+// TestBootstrap checks a clear core is recovered by every replicate and replicates are reproducible
+func TestBootstrap(t *testing.T) {
+	// AT-rich flanks around a GC-rich core, offset within the alignment
+	seq := strings.Repeat("A", 5) + strings.Repeat("AT", 10) + strings.Repeat("GC", 10) + strings.Repeat("TA", 10)
+	aln := nexus.Alignment{seq, seq, seq, seq}
+	start, stop := 5, len(seq)
+	mets := map[metrics.Metric][]float64{metrics.GC: metrics.SitewiseGc(&aln)}
+	opts := uce.Options{MinWin: 10, Candidates: 3}
+	best := uce.Exhaustive.Process(start, stop, mets, opts)
+	if exp := windows.New(25, 45); best[metrics.GC].Window != exp {
+		t.Fatalf("Got best window %v, Expected %v", best[metrics.GC].Window, exp)
+	}
+
+	for _, r := range []uce.Resample{uce.Taxa, uce.Sites, uce.Both} {
+		bounds := uce.Bootstrap(aln, []byte("ACGT"), start, stop, best, uce.Exhaustive, opts, r, 20, rand.New(rand.NewSource(1)))
+		b := bounds[metrics.GC]
+		if len(b.Starts) != 20 || len(b.Stops) != 20 {
+			t.Fatalf("%s: Expected 20 replicates, got %d starts and %d stops", r, len(b.Starts), len(b.Stops))
+		}
+		for i := range b.Starts {
+			if b.Starts[i] != 25 || b.Stops[i] != 45 {
+				t.Errorf("%s: Replicate %d chose (%d, %d), Expected (25, 45)", r, i, b.Starts[i], b.Stops[i])
+			}
+		}
+		again := uce.Bootstrap(aln, []byte("ACGT"), start, stop, best, uce.Exhaustive, opts, r, 20, rand.New(rand.NewSource(1)))
+		if !reflect.DeepEqual(bounds, again) {
+			t.Errorf("%s: Replicates with the same seed differ", r)
+		}
+	}
+}
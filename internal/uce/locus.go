@@ -0,0 +1,119 @@
+package uce
+
+import (
+	"sort"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// Locus is the alignment sites (0-based) of a UCE in order, which may be several disjoint ranges.
+// A UCE is analysed as its sites concatenated, sites [0, Len()), then mapped back to alignment positions.
+type Locus []int
+
+// NewLocus is the sites of 1-based, exclusive-stop ranges, overlapping ranges count each site once
+func NewLocus(pairs []nexus.Pair) Locus {
+	seen := make(map[int]bool)
+	var l Locus
+	for _, p := range pairs {
+		for s := p.First() - 1; s < p.Second()-1; s++ {
+			if !seen[s] {
+				seen[s] = true
+				l = append(l, s)
+			}
+		}
+	}
+	sort.Ints(l)
+	return l
+}
+
+// Len is the number of sites in the Locus
+func (l Locus) Len() int {
+	return len(l)
+}
+
+// Contiguous is whether the Locus is a single range of sites
+func (l Locus) Contiguous() bool {
+	return len(l) == 0 || l[len(l)-1]-l[0] == len(l)-1
+}
+
+// Start is the alignment position of the concatenated position p, a start (or Len() for the end of the Locus)
+func (l Locus) Start(p int) int {
+	if p == len(l) {
+		return l[p-1] + 1
+	}
+	return l[p]
+}
+
+// Stop is the exclusive alignment stop of the concatenated exclusive stop p (or the start of the Locus for zero)
+func (l Locus) Stop(p int) int {
+	if p == 0 {
+		return l[0]
+	}
+	return l[p-1] + 1
+}
+
+// Window is the alignment window spanning the concatenated window
+// An empty window is placed at its alignment position, so empty flanks remain empty
+func (l Locus) Window(w windows.Window) windows.Window {
+	if w.Start() == w.Stop() {
+		p := l.Start(w.Start())
+		return windows.New(p, p)
+	}
+	return windows.New(l.Start(w.Start()), l.Stop(w.Stop()))
+}
+
+// Ranges are the disjoint alignment ranges [start, stop) covered by the concatenated sites [start, stop)
+func (l Locus) Ranges(start, stop int) [][2]int {
+	var rs [][2]int
+	for i := start; i < stop; i++ {
+		if 0 < len(rs) && rs[len(rs)-1][1] == l[i] {
+			rs[len(rs)-1][1]++
+			continue
+		}
+		rs = append(rs, [2]int{l[i], l[i] + 1})
+	}
+	return rs
+}
+
+// Best maps each best window, and any segments, from concatenated sites back to alignment positions
+func (l Locus) Best(best map[metrics.Metric]windows.Best) map[metrics.Metric]windows.Best {
+	out := make(map[metrics.Metric]windows.Best, len(best))
+	for m, b := range best {
+		b.Window = l.Window(b.Window)
+		if b.Segments != nil {
+			segs := make([]windows.Window, len(b.Segments))
+			for i, s := range b.Segments {
+				segs[i] = l.Window(s)
+			}
+			b.Segments = segs
+		}
+		out[m] = b
+	}
+	return out
+}
+
+// Boundaries maps bootstrap core boundaries from concatenated sites back to alignment positions
+func (l Locus) Boundaries(bounds map[metrics.Metric]Boundaries) map[metrics.Metric]Boundaries {
+	out := make(map[metrics.Metric]Boundaries, len(bounds))
+	for m, b := range bounds {
+		var mapped Boundaries
+		for _, p := range b.Starts {
+			mapped.Starts = append(mapped.Starts, l.Start(p))
+		}
+		for _, p := range b.Stops {
+			mapped.Stops = append(mapped.Stops, l.Stop(p))
+		}
+		out[m] = mapped
+	}
+	return out
+}
+
+// Alignment is the columns of the alignment at the sites of the Locus, in order
+func (l Locus) Alignment(aln nexus.Alignment) nexus.Alignment {
+	if 0 < len(l) && l.Contiguous() {
+		return aln.Subseq(l[0], l[len(l)-1]+1)
+	}
+	return resample(aln, identity(int(aln.NSeq())), l)
+}
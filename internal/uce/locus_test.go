@@ -0,0 +1,83 @@
+package uce_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/uce"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestNewLocus(t *testing.T) {
+	tt := []struct {
+		name       string
+		pairs      []nexus.Pair
+		exp        uce.Locus
+		contiguous bool
+	}{
+		{"single range", []nexus.Pair{{3, 7}}, uce.Locus{2, 3, 4, 5}, true},
+		{"disjoint ranges", []nexus.Pair{{1, 3}, {6, 8}}, uce.Locus{0, 1, 5, 6}, false},
+		{"out of order", []nexus.Pair{{6, 8}, {1, 3}}, uce.Locus{0, 1, 5, 6}, false},
+		{"overlapping ranges", []nexus.Pair{{1, 4}, {3, 6}}, uce.Locus{0, 1, 2, 3, 4}, true},
+		{"single site", []nexus.Pair{{4, 5}}, uce.Locus{3}, true},
+	}
+	for _, tc := range tt {
+		got := uce.NewLocus(tc.pairs)
+		if !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got, tc.exp)
+		}
+		if got.Contiguous() != tc.contiguous {
+			t.Errorf("%s: Got contiguous %v, Expected %v", tc.name, got.Contiguous(), tc.contiguous)
+		}
+	}
+}
+
+func TestLocusMapping(t *testing.T) {
+	// Sites 10-14 and 20-24, 0-based
+	l := uce.NewLocus([]nexus.Pair{{11, 16}, {21, 26}})
+	tt := []struct {
+		name   string
+		w      windows.Window
+		exp    windows.Window
+		ranges [][2]int
+	}{
+		{"within first range", windows.New(1, 4), windows.New(11, 14), [][2]int{{11, 14}}},
+		{"across the gap", windows.New(3, 7), windows.New(13, 22), [][2]int{{13, 15}, {20, 22}}},
+		{"whole locus", windows.New(0, 10), windows.New(10, 25), [][2]int{{10, 15}, {20, 25}}},
+		{"empty at end", windows.New(10, 10), windows.New(25, 25), nil},
+		{"empty at start", windows.New(0, 0), windows.New(10, 10), nil},
+	}
+	for _, tc := range tt {
+		if got := l.Window(tc.w); got != tc.exp {
+			t.Errorf("%s: Got window %v, Expected %v", tc.name, got, tc.exp)
+		}
+		if got := l.Ranges(tc.w.Start(), tc.w.Stop()); !reflect.DeepEqual(got, tc.ranges) {
+			t.Errorf("%s: Got ranges %v, Expected %v", tc.name, got, tc.ranges)
+		}
+	}
+
+	best := l.Best(map[metrics.Metric]windows.Best{
+		metrics.GC: {Window: windows.New(3, 7), Segments: []windows.Window{windows.New(0, 3), windows.New(3, 10)}},
+	})[metrics.GC]
+	if best.Window != windows.New(13, 22) {
+		t.Errorf("Best: Got window %v, Expected %v", best.Window, windows.New(13, 22))
+	}
+	expSegs := []windows.Window{windows.New(10, 13), windows.New(13, 25)}
+	if !reflect.DeepEqual(best.Segments, expSegs) {
+		t.Errorf("Best: Got segments %v, Expected %v", best.Segments, expSegs)
+	}
+}
+
+func TestLocusAlignment(t *testing.T) {
+	l := uce.NewLocus([]nexus.Pair{{1, 3}, {5, 6}})
+	aln := nexus.Alignment{"ACGTAC", "TTGGCC"}
+	if got, exp := l.Alignment(aln), (nexus.Alignment{"ACA", "TTC"}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Alignment: Got %v, Expected %v", got, exp)
+	}
+	contiguous := uce.NewLocus([]nexus.Pair{{2, 5}})
+	if got, exp := contiguous.Alignment(aln), (nexus.Alignment{"CGT", "TGG"}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Contiguous Alignment: Got %v, Expected %v", got, exp)
+	}
+}
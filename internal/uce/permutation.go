@@ -0,0 +1,38 @@
+package uce
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// Permutation tests whether each metric's best window within the UCE [start, stop) beats chance.
+// The metric's values are shuffled within the UCE n times and each shuffle is searched again;
+// the p-value is the fraction of shuffles (counting the original) whose best objective is at least as good.
+func Permutation(start, stop int, mets map[metrics.Metric][]float64, best map[metrics.Metric]windows.Best, s Search, opts Options, n uint, rng *rand.Rand) map[metrics.Metric]float64 {
+	// Metrics in order so draws from rng are reproducible
+	ms := make([]metrics.Metric, 0, len(best))
+	for m := range best {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+
+	pValues := make(map[metrics.Metric]float64, len(ms))
+	for _, m := range ms {
+		observed := best[m].Score
+		shuffled := make([]float64, stop-start)
+		copy(shuffled, mets[m][start:stop])
+		asGood := 0
+		for i := uint(0); i < n; i++ {
+			rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			got, ok := s.Process(0, len(shuffled), map[metrics.Metric][]float64{m: shuffled}, opts)[m]
+			if ok && got.Score <= observed+opts.Ranking.ObjTol {
+				asGood++
+			}
+		}
+		pValues[m] = float64(1+asGood) / float64(1+n)
+	}
+	return pValues
+}
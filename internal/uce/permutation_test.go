@@ -0,0 +1,49 @@
+package uce_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/uce"
+)
+
+func TestPermutation(t *testing.T) {
+	const (
+		n     = 60
+		perms = 99
+	)
+	noise := rand.New(rand.NewSource(7))
+	structured := make([]float64, n)
+	random := make([]float64, n)
+	for i := range structured {
+		if 20 <= i && i < 40 {
+			structured[i] = 1
+		}
+		random[i] = noise.Float64()
+	}
+	opts := uce.Options{MinWin: 10, Candidates: 3}
+	tt := []struct {
+		name        string
+		vals        []float64
+		significant bool
+	}{
+		{"structured", structured, true},
+		{"random", random, false},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.vals}
+		best := uce.Exhaustive.Process(0, n, mets, opts)
+		got := uce.Permutation(0, n, mets, best, uce.Exhaustive, opts, perms, rand.New(rand.NewSource(1)))[metrics.GC]
+		if (got <= 0.05) != tc.significant {
+			t.Errorf("%s: Got p-value %v, Expected significant: %t", tc.name, got, tc.significant)
+		}
+		if got < 1.0/(perms+1) || 1 < got {
+			t.Errorf("%s: Got p-value %v, Expected within [%v, 1]", tc.name, got, 1.0/(perms+1))
+		}
+		again := uce.Permutation(0, n, mets, best, uce.Exhaustive, opts, perms, rand.New(rand.NewSource(1)))[metrics.GC]
+		if got != again {
+			t.Errorf("%s: Permutations with the same seed differ, %v and %v", tc.name, got, again)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package uce
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+// Problems are every problem found with UCE ranges, reported together
+type Problems []string
+
+func (p Problems) Error() string {
+	return fmt.Sprintf("%d problems with UCE ranges:\n  %s", len(p), strings.Join(p, "\n  "))
+}
+
+// span is one range of a named UCE, 1-based with an exclusive stop
+type span struct {
+	name        string
+	start, stop int
+}
+
+// spans are every range of the UCEs, sorted by start then name
+func spans(uces map[string][]nexus.Pair) []span {
+	var ss []span
+	for name, pairs := range uces {
+		for _, p := range pairs {
+			ss = append(ss, span{name, p.First(), p.Second()})
+		}
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].start != ss[j].start {
+			return ss[i].start < ss[j].start
+		}
+		if ss[i].name != ss[j].name {
+			return ss[i].name < ss[j].name
+		}
+		return ss[i].stop < ss[j].stop
+	})
+	return ss
+}
+
+// Validate checks UCE ranges for an alignment of n sites, reporting every problem at once:
+// names given more than once (dups), ranges outside the alignment, and, unless overlap is allowed,
+// UCEs sharing a start or any site. Ranges of one UCE may overlap each other.
+func Validate(uces map[string][]nexus.Pair, dups []string, n int, allowOverlap bool) error {
+	var probs Problems
+	seen := make(map[string]bool)
+	for _, name := range dups {
+		if !seen[name] {
+			seen[name] = true
+			probs = append(probs, fmt.Sprintf("duplicate name %s", name))
+		}
+	}
+
+	ss := spans(uces)
+	for _, s := range ss {
+		if s.start < 1 || n < s.stop-1 {
+			probs = append(probs, fmt.Sprintf("%s range %d-%d is outside the alignment of %d sites", s.name, s.start, s.stop-1, n))
+		}
+	}
+	if allowOverlap {
+		return probs.orNil()
+	}
+
+	starts := make(map[int][]string)
+	for _, name := range Order(uces) {
+		if l := NewLocus(uces[name]); len(l) != 0 {
+			starts[l[0]] = append(starts[l[0]], name)
+		}
+	}
+	var shared []int
+	for start, names := range starts {
+		if 1 < len(names) {
+			shared = append(shared, start)
+		}
+	}
+	sort.Ints(shared)
+	for _, start := range shared {
+		probs = append(probs, fmt.Sprintf("%s share start %d", strings.Join(starts[start], ", "), start+1))
+	}
+
+	// Sweep ranges in start order, each is compared to the earlier ranges it may overlap
+	reported := make(map[[2]string]bool)
+	var active []span
+	for _, s := range ss {
+		kept := active[:0]
+		for _, a := range active {
+			if s.start < a.stop {
+				kept = append(kept, a)
+			}
+		}
+		active = kept
+		for _, a := range active {
+			pair := [2]string{a.name, s.name}
+			if a.name == s.name || reported[pair] {
+				continue
+			}
+			reported[pair] = true
+			stop := a.stop
+			if s.stop < stop {
+				stop = s.stop
+			}
+			probs = append(probs, fmt.Sprintf("%s and %s overlap at %d-%d", a.name, s.name, s.start, stop-1))
+		}
+		active = append(active, s)
+	}
+	return probs.orNil()
+}
+
+// orNil is the Problems as an error, nil when there are none
+func (p Problems) orNil() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// Order is the UCE names sorted by their first site, then by name so UCEs sharing a start keep a fixed order
+func Order(uces map[string][]nexus.Pair) []string {
+	first := make(map[string]int, len(uces))
+	names := make([]string, 0, len(uces))
+	for name, pairs := range uces {
+		l := NewLocus(pairs)
+		first[name] = -1
+		if len(l) != 0 {
+			first[name] = l[0]
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if first[names[i]] != first[names[j]] {
+			return first[names[i]] < first[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
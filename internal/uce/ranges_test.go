@@ -0,0 +1,70 @@
+package uce_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/uce"
+)
+
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		name         string
+		uces         map[string][]nexus.Pair
+		dups         []string
+		allowOverlap bool
+		exp          []string
+	}{
+		{"valid", map[string][]nexus.Pair{"a": {{1, 11}}, "b": {{11, 21}}}, nil, false, nil},
+		{"disjoint ranges of one UCE", map[string][]nexus.Pair{"a": {{1, 6}, {15, 21}}, "b": {{6, 15}}}, nil, false, nil},
+		{"own ranges overlap", map[string][]nexus.Pair{"a": {{1, 11}, {5, 15}}}, nil, false, nil},
+		{"duplicate name", map[string][]nexus.Pair{"a": {{1, 11}}}, []string{"a", "a"}, false,
+			[]string{"duplicate name a"}},
+		{"out of range", map[string][]nexus.Pair{"a": {{0, 11}}, "b": {{15, 22}}}, nil, false,
+			[]string{"a range 0-10 is outside", "b range 15-21 is outside"}},
+		{"shared start", map[string][]nexus.Pair{"a": {{1, 11}}, "b": {{1, 6}}}, nil, false,
+			[]string{"a, b share start 1", "a and b overlap at 1-5"}},
+		{"overlap", map[string][]nexus.Pair{"a": {{1, 11}}, "b": {{8, 15}}, "c": {{14, 21}}}, nil, false,
+			[]string{"a and b overlap at 8-10", "b and c overlap at 14-14"}},
+		{"allowed overlap", map[string][]nexus.Pair{"a": {{1, 11}}, "b": {{1, 15}}}, nil, true, nil},
+		{"allowed overlap out of range", map[string][]nexus.Pair{"a": {{1, 11}}, "b": {{1, 25}}}, nil, true,
+			[]string{"b range 1-24 is outside"}},
+	}
+	for _, tc := range tt {
+		err := uce.Validate(tc.uces, tc.dups, 20, tc.allowOverlap)
+		if tc.exp == nil {
+			if err != nil {
+				t.Errorf("%s: Got %v, Expected no error", tc.name, err)
+			}
+			continue
+		}
+		probs, ok := err.(uce.Problems)
+		if !ok {
+			t.Errorf("%s: Got %v, Expected Problems", tc.name, err)
+			continue
+		}
+		if len(probs) != len(tc.exp) {
+			t.Errorf("%s: Got %d problems %q, Expected %d", tc.name, len(probs), probs, len(tc.exp))
+			continue
+		}
+		for i, p := range probs {
+			if !strings.HasPrefix(p, tc.exp[i]) {
+				t.Errorf("%s: Got %q, Expected prefix %q", tc.name, p, tc.exp[i])
+			}
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	uces := map[string][]nexus.Pair{
+		"c": {{30, 40}},
+		"b": {{1, 10}},
+		"a": {{1, 5}},
+		"d": {{50, 60}, {11, 20}},
+	}
+	if got, exp := uce.Order(uces), []string{"a", "b", "d", "c"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Got %v, Expected %v", got, exp)
+	}
+}
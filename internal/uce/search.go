@@ -0,0 +1,84 @@
+package uce
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// Search is the strategy used to find the best window of a UCE
+type Search int
+
+const (
+	// Heuristic extends the best candidate windows (fast, not guaranteed optimal)
+	Heuristic Search = iota
+
+	// Exhaustive scores every valid window (slow, optimal)
+	Exhaustive
+)
+
+func (s Search) String() string {
+	switch s {
+	case Heuristic:
+		return "heuristic"
+	case Exhaustive:
+		return "exhaustive"
+	default:
+		return ""
+	}
+}
+
+// ParseSearch converts the name of a search strategy to its Search
+func ParseSearch(name string) (Search, error) {
+	for _, s := range []Search{Heuristic, Exhaustive} {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown search %q, expected heuristic or exhaustive", name)
+}
+
+// Process finds the best window for each metric within the UCE [start, stop) using the search strategy
+func (s Search) Process(start, stop int, mets map[metrics.Metric][]float64, opts Options) map[metrics.Metric]windows.Best {
+	if s == Exhaustive {
+		return ProcessUceExhaustive(start, stop, mets, opts)
+	}
+	return ProcessUce(start, stop, mets, opts)
+}
+
+// ProcessUceExhaustive scores every window with flanks and core fitting opts.Sizes,
+// returning the optimal window for each metric
+func ProcessUceExhaustive(start, stop int, mets map[metrics.Metric][]float64, opts Options) map[metrics.Metric]windows.Best {
+	return windows.GetBestExhaustive(mets, start, stop, opts.Sizes(stop-start), opts.Ranking)
+}
+
+// Gap compares the window found by the Heuristic search to the Exhaustive optimum
+type Gap struct {
+	Heuristic, Exhaustive           windows.Window
+	HeuristicScore, ExhaustiveScore float64
+}
+
+// Diff is how far the Heuristic's objective falls from the Exhaustive optimum (zero if optimal)
+func (g Gap) Diff() float64 {
+	return g.HeuristicScore - g.ExhaustiveScore
+}
+
+// Gaps compares the Heuristic and Exhaustive best windows of each metric found in both
+func Gaps(heuristic, exhaustive map[metrics.Metric]windows.Best) map[metrics.Metric]Gap {
+	gaps := make(map[metrics.Metric]Gap, len(heuristic))
+	for m, h := range heuristic {
+		e, ok := exhaustive[m]
+		if !ok {
+			continue
+		}
+		gaps[m] = Gap{
+			Heuristic:       h.Window,
+			Exhaustive:      e.Window,
+			HeuristicScore:  h.Score,
+			ExhaustiveScore: e.Score,
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,67 @@
+package uce_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/uce"
+)
+
+func TestParseSearch(t *testing.T) {
+	tt := []struct {
+		name  string
+		exp   uce.Search
+		valid bool
+	}{
+		{"heuristic", uce.Heuristic, true},
+		{"exhaustive", uce.Exhaustive, true},
+		{"Exhaustive", uce.Exhaustive, true},
+		{"greedy", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := uce.ParseSearch(tc.name)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseSearch(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.name, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseSearch(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
+
+// TestExhaustiveIsOptimal checks the heuristic never beats the exhaustive optimum on the example data
+func TestExhaustiveIsOptimal(t *testing.T) {
+	nex := readExample(t)
+	aln := nex.Alignment()
+	letters := nex.Letters()
+	mets := map[metrics.Metric][]float64{
+		metrics.Entropy: metrics.SitewiseEntropy(&aln, letters),
+		metrics.GC:      metrics.SitewiseGc(&aln),
+	}
+	minWin := uint(50)
+	opts := uce.Options{MinWin: minWin, Candidates: 3}
+
+	for name, pairs := range nex.Charsets() {
+		start, stop := pairs[0].First()-1, pairs[0].Second()-1 // Charsets are 1-based
+		heuristic := uce.Heuristic.Process(start, stop, mets, opts)
+		exhaustive := uce.Exhaustive.Process(start, stop, mets, opts)
+		gaps := uce.Gaps(heuristic, exhaustive)
+		if len(gaps) != len(mets) {
+			t.Errorf("%s: Expected a gap for each of %d metrics, got %d", name, len(mets), len(gaps))
+		}
+		for m, g := range gaps {
+			if g.Diff() < -1e-9 {
+				t.Errorf("%s %s: heuristic %v (%v) beat exhaustive %v (%v)",
+					name, m, g.Heuristic, g.HeuristicScore, g.Exhaustive, g.ExhaustiveScore,
+				)
+			}
+			left := g.Exhaustive.Start() - start
+			core := g.Exhaustive.Stop() - g.Exhaustive.Start()
+			right := stop - g.Exhaustive.Stop()
+			if left < int(minWin) || core < int(minWin) || right < int(minWin) {
+				t.Errorf("%s %s: exhaustive window %v has a block shorter than %d", name, m, g.Exhaustive, minWin)
+			}
+		}
+	}
+}
@@ -1,24 +1,106 @@
 package uce
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
 	"github.com/rhagenson/swsc/internal/windows"
 )
 
+// Values are each metric computed over a single UCE's alignment,
+// so no value (e.g. the multinomial's character frequencies) depends on the other UCEs
+func Values(aln *nexus.Alignment, letters []byte, mets []metrics.Metric) map[metrics.Metric][]float64 {
+	out := make(map[metrics.Metric][]float64, len(mets))
+	for _, m := range mets {
+		out[m] = metrics.Compute(m, aln, letters)
+	}
+	return out
+}
+
+// Options control how the best window of a UCE is found
+type Options struct {
+	MinWin     uint              // Minimum length of the core and of each flank, unless set by MinCore or MinFlank
+	MinCore    Length            // Minimum length of the core, zero is MinWin
+	MinFlank   Length            // Minimum length of each flank, zero is MinWin
+	MaxCore    Length            // Maximum length of the core, zero is no limit
+	Candidates uint              // Number of best candidate windows extended by the Heuristic search
+	Ranking    windows.Ranking   // How windows are scored and ordered
+	Criterion  windows.Criterion // Chooses how many blocks a UCE is partitioned into
+	Segments   uint              // Number of segments for DP and BinSeg, zero is chosen by Criterion or else Penalty (DP) or by Penalty (BinSeg)
+	Penalty    float64           // Objective cost of each segment for PELT, BinSeg, and DP without a Criterion, zero is windows.DefaultPenalty
+}
+
+// Length is a block length in sites or, if below one, a fraction of the UCE length
+type Length float64
+
+// ParseLength converts a number of sites (e.g. 50) or a fraction of the UCE length (e.g. 0.1) to its Length
+func ParseLength(s string) (Length, error) {
+	var v float64
+	if _, err := fmt.Sscan(s, &v); err != nil {
+		return 0, fmt.Errorf("invalid length %q, expected a number of sites or a fraction of the UCE", s)
+	}
+	l := Length(v)
+	if err := l.Validate(); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
+// Validate checks the Length is a whole number of sites or a fraction between zero and one
+func (l Length) Validate() error {
+	if l < 0 || (1 <= l && l != Length(math.Trunc(float64(l)))) {
+		return fmt.Errorf("invalid length %v, expected a whole number of sites or a fraction of the UCE", float64(l))
+	}
+	return nil
+}
+
+// Sites is the number of sites of the Length within a UCE of n sites, fractions rounded to the nearest site
+func (l Length) Sites(n int) int {
+	if l < 1 {
+		return int(math.Round(float64(l) * float64(n)))
+	}
+	return int(l)
+}
+
+// Sizes are the block lengths, in sites, within a UCE of n sites
+// A fraction of the UCE is at least one site, and a longest core shorter than the shortest core is raised to it
+func (o Options) Sizes(n int) windows.Sizes {
+	s := windows.Sizes{
+		MinCore:  int(o.MinWin),
+		MinFlank: int(o.MinWin),
+		MaxCore:  o.MaxCore.Sites(n),
+	}
+	if o.MinCore != 0 {
+		s.MinCore = o.MinCore.Sites(n)
+	}
+	if o.MinFlank != 0 {
+		s.MinFlank = o.MinFlank.Sites(n)
+	}
+	if s.MinCore < 1 {
+		s.MinCore = 1
+	}
+	if s.MinFlank < 1 {
+		s.MinFlank = 1
+	}
+	if o.MaxCore != 0 && s.MaxCore < s.MinCore {
+		s.MaxCore = s.MinCore
+	}
+	return s
+}
+
 // ProcessUce computes the corresponding metrics within the minimum window size,
 // returning the best window and list of values for each metric
-func ProcessUce(start, stop int, mets map[metrics.Metric][]float64, minWin uint, chars []byte, largeCore bool, n uint) map[metrics.Metric]windows.Window {
-	var (
-		metricBestWindow = make(map[metrics.Metric]windows.Window, len(mets))
-	)
+// Only sites of the UCE [start, stop) are considered, mets may cover the whole alignment
+func ProcessUce(start, stop int, mets map[metrics.Metric][]float64, opts Options) map[metrics.Metric]windows.Best {
+	sizes := opts.Sizes(stop - start)
 
 	// Heuristic: Get nonoverlapping candidate windows
-	canWins := windows.GenerateCandidates(start, stop, int(minWin))
+	canWins := windows.GenerateCandidates(start, stop, sizes)
 
 	// Determine the best candidate window
-	bestCanWins := windows.GetBestN(mets, canWins, stop, largeCore, n)
+	bestCanWins := windows.GetBestN(mets, canWins, start, stop, opts.Ranking, opts.Candidates)
 
 	// Extend the best candidates and retest
 	// Also find the encompassing Window for testing (could be whole sequence)
@@ -29,7 +111,7 @@ func ProcessUce(start, stop int, mets map[metrics.Metric][]float64, minWin uint,
 	)
 	for _, wins := range bestCanWins {
 		for _, w := range wins {
-			extWins = append(extWins, windows.ExtendCandidate(w, start, stop, int(minWin))...)
+			extWins = append(extWins, windows.ExtendCandidate(w, start, stop, sizes)...)
 			if w.Start() < winStart {
 				winStart = w.Start()
 			}
@@ -38,9 +120,9 @@ func ProcessUce(start, stop int, mets map[metrics.Metric][]float64, minWin uint,
 			}
 		}
 	}
-	extWins = append(extWins, windows.New(winStart, winStop))
-
-	metricBestWindow = windows.GetBest(mets, extWins, stop, largeCore)
+	if encompassing := windows.New(winStart, winStop); sizes.Fits(encompassing, start, stop) {
+		extWins = append(extWins, encompassing)
+	}
 
-	return metricBestWindow
+	return windows.GetBest(mets, extWins, start, stop, opts.Ranking)
 }
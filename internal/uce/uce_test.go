@@ -1,6 +1,7 @@
 package uce_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/rhagenson/swsc/internal/metrics"
@@ -187,7 +188,7 @@ func TestProcessUce(t *testing.T) {
 			2,
 			[]byte("ATGC"),
 			map[metrics.Metric]windows.Window{
-				metrics.Entropy: windows.Window{3, 5},
+				metrics.Entropy: windows.Window{3, 5}, // All windows tie, the flanks and core are most even
 				metrics.GC:      windows.Window{3, 5},
 			},
 			map[metrics.Metric][]float64{
@@ -216,14 +217,174 @@ func TestProcessUce(t *testing.T) {
 		},
 	}
 	for _, tc := range tt {
-		gotWins := uce.ProcessUce(0, tc.aln.Len()-1, tc.metVals, tc.minWin, tc.chars, tc.largeCore, 3)
+		opts := uce.Options{MinWin: tc.minWin, Candidates: 3, Ranking: windows.Ranking{LargeCore: tc.largeCore}}
+		gotWins := uce.ProcessUce(0, tc.aln.Len(), tc.metVals, opts)
 		t.Run("Windows", func(t *testing.T) {
 			for m, got := range gotWins {
 				exp := tc.expWins[m]
-				if got != exp {
+				if got.Window != exp {
 					t.Errorf("\nGot:\n%v\nExpected:\n%v\nFor:\n%v", got, exp, tc.aln)
 				}
 			}
 		})
 	}
 }
+
+// concat joins alignments site-wise (i.e. each sequence is extended)
+func concat(alns ...nexus.Alignment) nexus.Alignment {
+	out := make(nexus.Alignment, alns[0].NSeq())
+	for _, aln := range alns {
+		for i := range out {
+			out[i] += aln[i]
+		}
+	}
+	return out
+}
+
+// readExample reads the example Nexus input shared by UCE tests
+func readExample(t *testing.T) *nexus.Nexus {
+	in, err := os.Open("../../example-data/example_input.nex")
+	if err != nil {
+		t.Fatalf("Could not open example input: %s", err)
+	}
+	defer in.Close()
+	nex, err := nexus.Read(in)
+	if err != nil {
+		t.Fatalf("Could not read example input: %s", err)
+	}
+	return nex
+}
+
+// TestProcessUceIsLocal checks a UCE's best window does not depend on the other UCEs in the alignment
+func TestProcessUceIsLocal(t *testing.T) {
+	nex := readExample(t)
+	aln := nex.Alignment()
+	letters := nex.Letters()
+	opts := uce.Options{MinWin: 50, Candidates: 3}
+	mets := []metrics.Metric{metrics.Entropy, metrics.GC, metrics.Multi}
+
+	// local finds the best windows of the UCE [start, stop) as swsc does, from metrics over the UCE's own columns
+	local := func(aln nexus.Alignment, start, stop int) map[metrics.Metric]windows.Window {
+		uceAln := aln.Subseq(start, stop)
+		best := make(map[metrics.Metric]windows.Window, len(mets))
+		for m, b := range uce.ProcessUce(0, stop-start, uce.Values(&uceAln, letters, mets), opts) {
+			best[m] = windows.New(b.Window.Start()+start, b.Window.Stop()+start)
+		}
+		return best
+	}
+
+	// whole holds metrics over the whole alignment, with the UCE [start, stop) holding its own values
+	// Per-site metrics can differ in the last bit when summed over other columns, which would break SSE ties
+	whole := func(start, stop int) map[metrics.Metric][]float64 {
+		uceAln := aln.Subseq(start, stop)
+		own := uce.Values(&uceAln, letters, mets)
+		vals := uce.Values(&aln, letters, mets)
+		for m := range vals {
+			copy(vals[m][start:stop], own[m])
+		}
+		return vals
+	}
+
+	// Any other UCE is used as filler when adding UCEs around the UCE under test
+	filler := aln.Subseq(0, 376)
+
+	for name, pairs := range nex.Charsets() {
+		start, stop := pairs[0].First()-1, pairs[0].Second()-1 // Charsets are 1-based
+		got := local(aln, start, stop)
+
+		t.Run(name+"/whole alignment", func(t *testing.T) {
+			for m, b := range uce.ProcessUce(start, stop, whole(start, stop), opts) {
+				if got[m] != b.Window {
+					t.Errorf("%s: Got %v from the UCE's columns, %v from whole alignment metrics", m, got[m], b.Window)
+				}
+			}
+		})
+		t.Run(name+"/removed", func(t *testing.T) {
+			alone := aln.Subseq(start, stop)
+			for _, m := range mets {
+				w := local(alone, 0, alone.Len())[m]
+				shifted := windows.New(w.Start()+start, w.Stop()+start)
+				if got[m] != shifted {
+					t.Errorf("%s: Got %v in full alignment, %v with other UCEs removed", m, got[m], shifted)
+				}
+			}
+		})
+		t.Run(name+"/added", func(t *testing.T) {
+			padded := concat(filler, aln.Subseq(start, stop), filler, filler)
+			offset := filler.Len()
+			for _, m := range mets {
+				w := local(padded, offset, offset+stop-start)[m]
+				shifted := windows.New(w.Start()-offset+start, w.Stop()-offset+start)
+				if got[m] != shifted {
+					t.Errorf("%s: Got %v in full alignment, %v with other UCEs added", m, got[m], shifted)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tt := []struct {
+		value string
+		exp   uce.Length
+		valid bool
+	}{
+		{"50", 50, true},
+		{"0.1", 0.1, true},
+		{"1", 1, true},
+		{"0", 0, true},
+		{"1.5", 0, false},
+		{"-3", 0, false},
+		{"auto", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := uce.ParseLength(tc.value)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseLength(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.value, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseLength(%q) => Got: nil error, Expected: !nil", tc.value)
+		}
+	}
+}
+
+func TestOptionsSizes(t *testing.T) {
+	tt := []struct {
+		name string
+		opts uce.Options
+		n    int
+		exp  windows.Sizes
+	}{
+		{"minWin alone", uce.Options{MinWin: 50}, 400, windows.Sizes{MinCore: 50, MinFlank: 50}},
+		{"sites", uce.Options{MinWin: 50, MinCore: 100, MinFlank: 10, MaxCore: 200}, 400, windows.Sizes{MinCore: 100, MinFlank: 10, MaxCore: 200}},
+		{"fractions", uce.Options{MinWin: 50, MinCore: 0.25, MinFlank: 0.1, MaxCore: 0.5}, 400, windows.Sizes{MinCore: 100, MinFlank: 40, MaxCore: 200}},
+		{"at least one site", uce.Options{MinCore: 0.01, MinFlank: 0.01}, 20, windows.Sizes{MinCore: 1, MinFlank: 1}},
+		{"longest core raised", uce.Options{MinWin: 50, MaxCore: 0.1}, 400, windows.Sizes{MinCore: 50, MinFlank: 50, MaxCore: 50}},
+	}
+	for _, tc := range tt {
+		if got := tc.opts.Sizes(tc.n); got != tc.exp {
+			t.Errorf("%s: Got %+v, Expected %+v", tc.name, got, tc.exp)
+		}
+	}
+}
+
+// TestProcessUceSizes checks both searches keep the core and flanks within the sizes
+func TestProcessUceSizes(t *testing.T) {
+	const n = 120
+	vals := make([]float64, n)
+	for i := 30; i < 100; i++ { // A long core with short right flank
+		vals[i] = 1
+	}
+	mets := map[metrics.Metric][]float64{metrics.GC: vals}
+	opts := uce.Options{MinWin: 30, MinCore: 10, MinFlank: 0.1, MaxCore: 50, Candidates: 3}
+	sizes := opts.Sizes(n)
+	for _, s := range []uce.Search{uce.Heuristic, uce.Exhaustive} {
+		got, ok := s.Process(0, n, mets, opts)[metrics.GC]
+		if !ok {
+			t.Fatalf("%s: no best window", s)
+		}
+		if !sizes.Fits(got.Window, 0, n) {
+			t.Errorf("%s: Got %v, Expected blocks fitting %+v", s, got.Window, sizes)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"path"
+	"strings"
 )
 
 // Header informs the user what work is being performed
@@ -14,3 +15,12 @@ func Header(f string) string {
 func Footer(f string) string {
 	return fmt.Sprintf("\nWrote partitions to %s\n", f)
 }
+
+// Summary informs the user how many UCEs ended with each status for a metric
+func Summary(metric string, statuses []string, counts []int) string {
+	parts := make([]string, len(statuses))
+	for i, s := range statuses {
+		parts[i] = fmt.Sprintf("%d %s", counts[i], s)
+	}
+	return fmt.Sprintf("%s: %s\n", metric, strings.Join(parts, ", "))
+}
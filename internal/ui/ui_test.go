@@ -40,3 +40,11 @@ func TestFooter(t *testing.T) {
 		}
 	}
 }
+
+func TestSummary(t *testing.T) {
+	got := ui.Summary("GC", []string{"ok", "too_short"}, []int{12, 3})
+	exp := "GC: 12 ok, 3 too_short\n"
+	if got != exp {
+		t.Errorf("Expected: %q, got %q", exp, got)
+	}
+}
@@ -1,44 +1,11 @@
 package utils_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/rhagenson/swsc/internal/utils"
-	"github.com/pkg/errors"
 )
 
-func TestValidateMinWin(t *testing.T) {
-	tt := []struct {
-		length int
-		minWin int
-		valid  bool
-	}{
-		{50, 50, false},
-		{100, 50, false},
-		{150, 50, true},
-		{200, 50, true},
-		{300, 10, true},
-	}
-
-	for _, tc := range tt {
-		got := utils.ValidateMinWin(tc.length, tc.minWin)
-		msg := fmt.Sprintf(
-			"minWin is too large, maximum allowed value is length/3 or %d\n",
-			tc.length/3,
-		)
-		if tc.valid {
-			if got == errors.New(msg) {
-				t.Errorf("ValidateMinWin(%d, %d) => Got: %v, Expected: %v", tc.length, tc.minWin, got, nil)
-			}
-		} else {
-			if got == nil {
-				t.Errorf("ValidateMinWin(%d, %d) => Got: %v, Expected: %v", tc.length, tc.minWin, got, "!nil")
-			}
-		}
-	}
-}
-
 func TestMinInCountsMap(t *testing.T) {
 	tt := []struct {
 		counts map[byte]int
@@ -0,0 +1,142 @@
+package windows
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+)
+
+// PELT finds, for each metric, the segmentation of the UCE [start, stop) minimising the summed objective value
+// plus penalty per segment, with each segment at least minWin long (Pruned Exact Linear Time).
+// A penalty of zero uses DefaultPenalty.
+func PELT(mets map[metrics.Metric][]float64, start, stop, minWin int, obj Objective, penalty float64) map[metrics.Metric]Best {
+	if minWin < 1 {
+		minWin = 1
+	}
+	best := make(map[metrics.Metric]Best, len(mets))
+	for m, p := range newPrefixes(mets, start, stop) {
+		pen := penalty
+		if pen == 0 {
+			pen = p.defaultPenalty(obj)
+		}
+		best[m] = p.segmented(p.pelt(minWin, obj, pen), obj)
+	}
+	return best
+}
+
+// BinSeg splits, for each metric, the UCE [start, stop) in two at the split which most lowers the objective,
+// then splits the resulting segments in turn, with each segment at least minWin long (Binary Segmentation).
+// Splitting stops at k segments or, if k is zero, once no split lowers the objective by more than penalty.
+// A penalty of zero uses DefaultPenalty.
+func BinSeg(mets map[metrics.Metric][]float64, start, stop, minWin, k int, obj Objective, penalty float64) map[metrics.Metric]Best {
+	if minWin < 1 {
+		minWin = 1
+	}
+	best := make(map[metrics.Metric]Best, len(mets))
+	for m, p := range newPrefixes(mets, start, stop) {
+		pen := penalty
+		if pen == 0 {
+			pen = p.defaultPenalty(obj)
+		}
+		best[m] = p.segmented(p.binSeg(minWin, k, obj, pen), obj)
+	}
+	return best
+}
+
+// pelt is the cuts (relative to the UCE start, from 0 to n) of the optimal penalised segmentation
+func (p *prefix) pelt(minWin int, obj Objective, pen float64) []int {
+	n := len(p.vals)
+	if n < 2*minWin {
+		return []int{0, n}
+	}
+	// Pruning relies on splitting a block never increasing its objective, which neither NormSSE
+	// nor SAD (taken around each block's mean, not its median) guarantees
+	prune := obj != NormSSE && obj != SAD
+
+	f := make([]float64, n+1) // f[t] is the lowest penalised objective of the first t sites
+	last := make([]int, n+1)  // last[t] is where the final segment of f[t] begins
+	prunedAt := make([]int, n+1)
+	for t := range f {
+		f[t] = math.Inf(1)
+		prunedAt[t] = -1
+	}
+	f[0] = -pen
+
+	var cands []int
+	for t := minWin; t <= n; t++ {
+		if s := t - minWin; s == 0 || minWin <= s && !math.IsInf(f[s], 1) {
+			cands = append(cands, s)
+		}
+		// A candidate beaten at time u can still end the last segment before u+minWin, where u cannot
+		kept := cands[:0]
+		for _, s := range cands {
+			if prunedAt[s] < 0 || t < prunedAt[s]+minWin {
+				kept = append(kept, s)
+			}
+		}
+		cands = kept
+
+		costs := make([]float64, len(cands))
+		for i, s := range cands {
+			costs[i] = f[s] + p.block(p.start+s, p.start+t, obj)
+			if costs[i]+pen < f[t] {
+				f[t] = costs[i] + pen
+				last[t] = s
+			}
+		}
+		if prune {
+			for i, s := range cands {
+				if prunedAt[s] < 0 && f[t] < costs[i] {
+					prunedAt[s] = t
+				}
+			}
+		}
+	}
+
+	cuts := []int{n}
+	for t := n; 0 < t; t = last[t] {
+		cuts = append(cuts, last[t])
+	}
+	for i, j := 0, len(cuts)-1; i < j; i, j = i+1, j-1 {
+		cuts[i], cuts[j] = cuts[j], cuts[i]
+	}
+	return cuts
+}
+
+// binSeg is the cuts (relative to the UCE start, from 0 to n) found by binary segmentation
+func (p *prefix) binSeg(minWin, k int, obj Objective, pen float64) []int {
+	n := len(p.vals)
+	cuts := []int{0, n}
+	for k == 0 || len(cuts)-1 < k {
+		bestGain, bestCut := math.Inf(-1), -1
+		for i := 0; i+1 < len(cuts); i++ {
+			a, b := cuts[i], cuts[i+1]
+			whole := p.block(p.start+a, p.start+b, obj)
+			for c := a + minWin; c <= b-minWin; c++ {
+				gain := whole - p.block(p.start+a, p.start+c, obj) - p.block(p.start+c, p.start+b, obj)
+				if bestGain < gain {
+					bestGain, bestCut = gain, c
+				}
+			}
+		}
+		if bestCut < 0 || (k == 0 && bestGain <= pen) {
+			break
+		}
+		cuts = append(cuts, bestCut)
+		sort.Ints(cuts)
+	}
+	return cuts
+}
+
+// segmented is the Best of a UCE cut at each of cuts (relative to the UCE start, from 0 to n)
+func (p *prefix) segmented(cuts []int, obj Objective) Best {
+	stop := p.start + len(p.vals)
+	segs := make([]Window, len(cuts)-1)
+	score := 0.0
+	for i := range segs {
+		segs[i] = New(p.start+cuts[i], p.start+cuts[i+1])
+		score += p.block(segs[i].Start(), segs[i].Stop(), obj)
+	}
+	return Best{Window: New(p.start, stop), Segments: segs, Score: score, Ties: 1}
+}
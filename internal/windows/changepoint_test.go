@@ -0,0 +1,101 @@
+package windows_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// TestPELTIsOptimal checks PELT matches the best penalised split found by dynamic programming over every k
+func TestPELTIsOptimal(t *testing.T) {
+	const (
+		start, stop = 3, 93
+		minWin      = 8
+	)
+	rng := rand.New(rand.NewSource(3))
+	vals := steps(stop, 30, 55, 70)
+	for i := range vals {
+		vals[i] += rng.NormFloat64() / 2
+	}
+	mets := map[metrics.Metric][]float64{metrics.GC: vals}
+	for _, obj := range []windows.Objective{windows.SSE, windows.NormSSE, windows.SAD, windows.GaussLL} {
+		for _, pen := range []float64{0.5, 5, windows.DefaultPenalty(vals, start, stop, obj)} {
+			exp := math.Inf(1)
+			for k := 1; k <= (stop-start)/minWin; k++ {
+				seg := windows.Segment(mets, start, stop, minWin, k, obj, windows.NoCriterion, 0)[metrics.GC]
+				exp = math.Min(exp, seg.Score+float64(len(seg.Segments))*pen)
+			}
+			got := windows.PELT(mets, start, stop, minWin, obj, pen)[metrics.GC]
+			if 1e-9 < math.Abs(got.Score+float64(len(got.Segments))*pen-exp) {
+				t.Errorf("%s, penalty %v: Got penalised score %v (%v), Expected %v",
+					obj, pen, got.Score+float64(len(got.Segments))*pen, got.Segments, exp)
+			}
+			for _, s := range got.Segments {
+				if s.Stop()-s.Start() < minWin {
+					t.Errorf("%s, penalty %v: Segment %v is shorter than %d", obj, pen, s, minWin)
+				}
+			}
+		}
+	}
+}
+
+// TestPELTIsOptimalSAD checks PELT under SAD, where splitting a block around its mean can increase its objective
+// e.g. 0 0 10 -10 0 0 is 20 as one block but 26.67 as 0 0 10 and -10 0 0
+func TestPELTIsOptimalSAD(t *testing.T) {
+	vals := []float64{0, 0, 10, -10, 0, 0, 5, 0, 0, 10, -10, 0, 0, -5, 0, 0, 10, -10, 0, 0}
+	mets := map[metrics.Metric][]float64{metrics.GC: vals}
+	for minWin := 1; minWin <= 4; minWin++ {
+		for _, pen := range []float64{0.5, 2, 5, windows.DefaultPenalty(vals, 0, len(vals), windows.SAD)} {
+			exp := math.Inf(1)
+			for k := 1; k <= len(vals)/minWin; k++ {
+				seg := windows.Segment(mets, 0, len(vals), minWin, k, windows.SAD, windows.NoCriterion, 0)[metrics.GC]
+				exp = math.Min(exp, seg.Score+float64(len(seg.Segments))*pen)
+			}
+			got := windows.PELT(mets, 0, len(vals), minWin, windows.SAD, pen)[metrics.GC]
+			if 1e-9 < math.Abs(got.Score+float64(len(got.Segments))*pen-exp) {
+				t.Errorf("minWin %d, penalty %v: Got penalised score %v (%v), Expected %v",
+					minWin, pen, got.Score+float64(len(got.Segments))*pen, got.Segments, exp)
+			}
+		}
+	}
+}
+
+func TestBinSeg(t *testing.T) {
+	const minWin = 10
+	vals := steps(90, 30, 60)
+	mets := map[metrics.Metric][]float64{metrics.GC: vals}
+	exp := []windows.Window{windows.New(0, 30), windows.New(30, 60), windows.New(60, 90)}
+	tt := []struct {
+		name string
+		k    int
+		pen  float64
+		exp  []windows.Window
+	}{
+		{"default penalty", 0, 0, exp},
+		{"fixed k", 3, 0, exp},
+		{"one segment", 1, 0, exp[:0]},
+		{"large penalty", 0, 1e6, exp[:0]},
+	}
+	for _, tc := range tt {
+		got := windows.BinSeg(mets, 0, 90, minWin, tc.k, windows.SSE, tc.pen)[metrics.GC]
+		if len(tc.exp) == 0 {
+			if len(got.Segments) != 1 || got.Segments[0] != windows.New(0, 90) {
+				t.Errorf("%s: Got %v, Expected the whole UCE", tc.name, got.Segments)
+			}
+			continue
+		}
+		if len(got.Segments) != len(tc.exp) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got.Segments, tc.exp)
+			continue
+		}
+		for i := range tc.exp {
+			if got.Segments[i] != tc.exp[i] {
+				t.Errorf("%s: Got %v, Expected %v", tc.name, got.Segments, tc.exp)
+				break
+			}
+		}
+	}
+}
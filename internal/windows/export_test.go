@@ -0,0 +1,39 @@
+package windows
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// naiveSse is the reference Sum of Square Errors, rescanning the block on every call
+func naiveSse(vs []float64) float64 {
+	mean := stat.Mean(vs, nil)
+	total := 0.0
+	for _, v := range vs {
+		total += math.Pow((v / mean), 2)
+	}
+	return total
+}
+
+// ScoreNaive scores each window by rescanning the flanks and core of the UCE [start, stop)
+func ScoreNaive(metric []float64, wins []Window, start, stop int) []float64 {
+	scores := make([]float64, len(wins))
+	for i, win := range wins {
+		scores[i] = naiveSse(metric[start:win.Start()]) +
+			naiveSse(metric[win.Start():win.Stop()]) +
+			naiveSse(metric[win.Stop():stop])
+	}
+	return scores
+}
+
+// ScorePrefix scores each window from cumulative sums precomputed once over the UCE [start, stop)
+// NormSSE plus the UCE length is the naive sum((v/mean)^2)
+func ScorePrefix(metric []float64, wins []Window, start, stop int) []float64 {
+	p := newPrefix(metric, start, stop)
+	scores := make([]float64, len(wins))
+	for i, win := range wins {
+		scores[i] = p.getSse(win, NormSSE) + float64(stop-start)
+	}
+	return scores
+}
@@ -0,0 +1,409 @@
+package windows
+
+import (
+	"math"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+)
+
+const (
+	// hmmMaxIter is the most Baum-Welch iterations used to fit an HMM
+	hmmMaxIter = 200
+
+	// hmmTol is the smallest improvement in log-likelihood for Baum-Welch to continue
+	hmmTol = 1e-6
+
+	// hmmMinStay keeps the chance of continuing, or ending, a block away from zero
+	hmmMinStay = 1e-6
+)
+
+// HMM fits, for each metric, a hidden Markov model of the UCE [start, stop) as a left flank, core, and right flank
+// and returns the core decoded by Viterbi along with the posterior probability that each site is in the core.
+// Both flanks share one Gaussian emission distribution and the core has its own, fit by Baum-Welch.
+// Each block fits the sizes. A UCE too short for three blocks is returned whole.
+func HMM(mets map[metrics.Metric][]float64, start, stop int, sizes Sizes, obj Objective) map[metrics.Metric]Best {
+	if sizes.MinCore < 1 {
+		sizes.MinCore = 1
+	}
+	if sizes.MinFlank < 1 {
+		sizes.MinFlank = 1
+	}
+	best := make(map[metrics.Metric]Best, len(mets))
+	for m, p := range newPrefixes(mets, start, stop) {
+		if len(p.vals) < sizes.MinLength() {
+			whole := New(start, stop)
+			best[m] = Best{Window: whole, Score: p.getSse(whole, obj), Ties: 1}
+			continue
+		}
+		h := newHMM(p, sizes)
+		h.fit(p.vals)
+		core := h.viterbi(p.vals)
+		win := New(start+core[0], start+core[1])
+		best[m] = Best{
+			Window:    win,
+			Posterior: h.corePosterior(p.vals),
+			Score:     p.getSse(win, obj),
+			Ties:      1,
+		}
+	}
+	return best
+}
+
+// Block groups of the HMM, in the order they are visited
+const (
+	hmmLeft = iota
+	hmmCore
+	hmmRight
+	hmmGroups
+)
+
+// Kinds of HMM transition
+const (
+	hmmForced   = iota // Always taken
+	hmmContinue        // Taken with the group's chance of continuing the block
+	hmmLeave           // Taken with the group's chance of ending the block
+)
+
+// hmmEdge is a transition between two HMM states
+type hmmEdge struct {
+	from, to int
+	group    int // Group the transition leaves from
+	kind     int
+}
+
+// hmm is a left-to-right HMM of three blocks, each a chain of states so every block is at least its minimum length.
+// A block without a longest length is a chain of its minimum length whose last state may stay;
+// a block with a longest length is a chain of that length which may be left from any state past its minimum.
+type hmm struct {
+	group    []int              // Group of each state
+	edges    []hmmEdge          // Transitions, in order of the state they leave from
+	cont     [hmmGroups]float64 // Chance a block continues from each state that may end it
+	mean     [2]float64         // Emission mean of the flanks (0) and core (1)
+	variance [2]float64         // Emission variance of the flanks (0) and core (1)
+}
+
+// newHMM starts from the three blocks with the lowest SSE, so Baum-Welch refines rather than discovers the core
+// Windows are scanned as GenerateWindows produces them, without holding them all in memory
+func newHMM(p *prefix, sizes Sizes) *hmm {
+	n := len(p.vals)
+	start := New(p.start, p.start+n)
+	bestSse := math.Inf(1)
+	eachWindow(n, sizes, func(w Window) {
+		w = New(p.start+w.Start(), p.start+w.Stop())
+		if sse := p.getSse(w, SSE); sse < bestSse {
+			bestSse, start = sse, w
+		}
+	})
+	left, right := start.Start()-p.start, start.Stop()-p.start
+
+	h := new(hmm)
+	flank := append(append([]float64{}, p.vals[:left]...), p.vals[right:]...)
+	h.mean[0], h.variance[0] = meanVariance(flank, nil)
+	h.mean[1], h.variance[1] = meanVariance(p.vals[left:right], nil)
+
+	mins := [hmmGroups]int{sizes.MinFlank, sizes.MinCore, sizes.MinFlank}
+	maxs := [hmmGroups]int{0, sizes.MaxCore, 0}
+	lengths := [hmmGroups]int{left, right - left, n - right}
+	for g := range mins {
+		first := len(h.group)
+		chain := mins[g]
+		if maxs[g] != 0 {
+			chain = maxs[g]
+		}
+		for j := 0; j < chain; j++ {
+			h.group = append(h.group, g)
+		}
+		for j := 0; j < chain; j++ {
+			s := first + j
+			switch {
+			case j < mins[g]-1: // Too short to end the block
+				h.edges = append(h.edges, hmmEdge{s, s + 1, g, hmmForced})
+			case g == hmmRight: // The right flank lasts until the end of the UCE
+				h.edges = append(h.edges, hmmEdge{s, s, g, hmmForced})
+			case j < chain-1: // May end the block, or move along the chain
+				h.edges = append(h.edges, hmmEdge{s, s + 1, g, hmmContinue}, hmmEdge{s, first + chain, g, hmmLeave})
+			case maxs[g] != 0: // Longest block, must end
+				h.edges = append(h.edges, hmmEdge{s, s + 1, g, hmmForced})
+			default: // May end the block, or stay
+				h.edges = append(h.edges, hmmEdge{s, s, g, hmmContinue}, hmmEdge{s, s + 1, g, hmmLeave})
+			}
+		}
+		// Geometric block lengths past the minimum, matching the starting blocks on average
+		h.cont[g] = clampStay(1 - 1/float64(lengths[g]-mins[g]+1))
+	}
+	return h
+}
+
+// states is the number of HMM states
+func (h *hmm) states() int {
+	return len(h.group)
+}
+
+// emission is the emission distribution used by state s, 0 for flanks and 1 for the core
+func (h *hmm) emission(s int) int {
+	if h.group[s] == hmmCore {
+		return 1
+	}
+	return 0
+}
+
+// prob is the chance of taking the transition
+func (h *hmm) prob(e hmmEdge) float64 {
+	switch e.kind {
+	case hmmContinue:
+		return h.cont[e.group]
+	case hmmLeave:
+		return 1 - h.cont[e.group]
+	default:
+		return 1
+	}
+}
+
+// logEmissions is the log density of each site under each emission distribution
+func (h *hmm) logEmissions(vals []float64) [][2]float64 {
+	le := make([][2]float64, len(vals))
+	for t, x := range vals {
+		for e := range le[t] {
+			d := x - h.mean[e]
+			le[t][e] = -0.5*math.Log(2*math.Pi*h.variance[e]) - d*d/(2*h.variance[e])
+		}
+	}
+	return le
+}
+
+// forward is the scaled forward variables, emission densities (scaled per site), and the log-likelihood
+func (h *hmm) forward(vals []float64) ([][]float64, [][2]float64, float64) {
+	n, S := len(vals), h.states()
+	le := h.logEmissions(vals)
+	em := make([][2]float64, n)
+	ll := 0.0
+	for t := range em {
+		shift := math.Max(le[t][0], le[t][1])
+		em[t] = [2]float64{math.Exp(le[t][0] - shift), math.Exp(le[t][1] - shift)}
+		ll += shift
+	}
+
+	alpha := make([][]float64, n)
+	for t := range alpha {
+		alpha[t] = make([]float64, S)
+		if t == 0 {
+			alpha[t][0] = em[t][h.emission(0)] // Always start in the left flank
+		} else {
+			for _, e := range h.edges {
+				alpha[t][e.to] += alpha[t-1][e.from] * h.prob(e)
+			}
+			for s := range alpha[t] {
+				alpha[t][s] *= em[t][h.emission(s)]
+			}
+		}
+		c := 0.0
+		for _, a := range alpha[t] {
+			c += a
+		}
+		for s := range alpha[t] {
+			alpha[t][s] /= c
+		}
+		ll += math.Log(c)
+	}
+	return alpha, em, ll
+}
+
+// backward is the backward variables scaled to match forward, ending in the right flank
+func (h *hmm) backward(alpha [][]float64, em [][2]float64) [][]float64 {
+	n, S := len(alpha), h.states()
+	beta := make([][]float64, n)
+	beta[n-1] = make([]float64, S)
+	beta[n-1][S-1] = 1
+	for t := n - 2; 0 <= t; t-- {
+		beta[t] = make([]float64, S)
+		for _, e := range h.edges {
+			beta[t][e.from] += h.prob(e) * em[t+1][h.emission(e.to)] * beta[t+1][e.to]
+		}
+		c := 0.0
+		for s := range beta[t] {
+			c += beta[t][s] * alpha[t][s]
+		}
+		if c == 0 {
+			continue
+		}
+		for s := range beta[t] {
+			beta[t][s] /= c
+		}
+	}
+	return beta
+}
+
+// hmmPass is the result of one forward-backward pass over a UCE
+type hmmPass struct {
+	alpha, beta [][]float64  // Scaled forward and backward variables
+	gamma       [][]float64  // Probability of each state at each site given the whole UCE
+	em          [][2]float64 // Emission densities, scaled per site
+	ll          float64      // Log-likelihood of the UCE
+}
+
+// pass runs forward-backward over the UCE
+func (h *hmm) pass(vals []float64) *hmmPass {
+	alpha, em, ll := h.forward(vals)
+	beta := h.backward(alpha, em)
+	gamma := make([][]float64, len(vals))
+	for t := range gamma {
+		gamma[t] = make([]float64, h.states())
+		total := 0.0
+		for s := range gamma[t] {
+			gamma[t][s] = alpha[t][s] * beta[t][s]
+			total += gamma[t][s]
+		}
+		for s := range gamma[t] {
+			if 0 < total {
+				gamma[t][s] /= total
+			}
+		}
+	}
+	return &hmmPass{alpha: alpha, beta: beta, gamma: gamma, em: em, ll: ll}
+}
+
+// fit re-estimates the emissions and block lengths by Baum-Welch
+func (h *hmm) fit(vals []float64) {
+	prevLL := math.Inf(-1)
+	for iter := 0; iter < hmmMaxIter; iter++ {
+		ps := h.pass(vals)
+		if ps.ll-prevLL < hmmTol {
+			break
+		}
+		prevLL = ps.ll
+
+		// Emissions, weighted by the chance each site is in a flank or the core
+		var weights [2][]float64
+		for e := range weights {
+			weights[e] = make([]float64, len(vals))
+		}
+		for t := range ps.gamma {
+			for s, g := range ps.gamma[t] {
+				weights[h.emission(s)][t] += g
+			}
+		}
+
+		// Chance of continuing each block, from the expected transitions which continue or end it
+		var cont, leave [hmmGroups]float64
+		xi := make([]float64, len(h.edges))
+		for t := 0; t+1 < len(vals); t++ {
+			norm := 0.0 // Total weight of every transition from t to t+1
+			for i, e := range h.edges {
+				xi[i] = ps.alpha[t][e.from] * h.prob(e) * ps.em[t+1][h.emission(e.to)] * ps.beta[t+1][e.to]
+				norm += xi[i]
+			}
+			if norm == 0 {
+				continue
+			}
+			for i, e := range h.edges {
+				switch e.kind {
+				case hmmContinue:
+					cont[e.group] += xi[i] / norm
+				case hmmLeave:
+					leave[e.group] += xi[i] / norm
+				}
+			}
+		}
+
+		for e := range weights {
+			h.mean[e], h.variance[e] = meanVariance(vals, weights[e])
+		}
+		for g := range h.cont {
+			if 0 < cont[g]+leave[g] {
+				h.cont[g] = clampStay(cont[g] / (cont[g] + leave[g]))
+			}
+		}
+	}
+}
+
+// viterbi is the core [start, stop) of the most likely path, relative to the UCE start
+func (h *hmm) viterbi(vals []float64) [2]int {
+	n, S := len(vals), h.states()
+	le := h.logEmissions(vals)
+
+	delta := make([]float64, S)
+	for s := range delta {
+		delta[s] = math.Inf(-1)
+	}
+	delta[0] = le[0][h.emission(0)]
+	from := make([][]int, n) // from[t][s] is the state before s at site t on the best path
+	for t := 1; t < n; t++ {
+		from[t] = make([]int, S)
+		next := make([]float64, S)
+		for s := range next {
+			next[s] = math.Inf(-1)
+			from[t][s] = -1
+		}
+		for _, e := range h.edges {
+			if d := delta[e.from] + math.Log(h.prob(e)); next[e.to] < d {
+				next[e.to] = d
+				from[t][e.to] = e.from
+			}
+		}
+		for s := range next {
+			next[s] += le[t][h.emission(s)]
+		}
+		delta = next
+	}
+
+	// Trace back from the end of the right flank
+	core := [2]int{-1, -1}
+	s := S - 1
+	for t := n - 1; 0 <= t; t-- {
+		if h.group[s] == hmmCore {
+			if core[1] < 0 {
+				core[1] = t + 1
+			}
+			core[0] = t
+		}
+		if 0 < t {
+			s = from[t][s]
+		}
+	}
+	return core
+}
+
+// corePosterior is the probability that each site of the UCE is in the core
+func (h *hmm) corePosterior(vals []float64) []float64 {
+	gamma := h.pass(vals).gamma
+	post := make([]float64, len(vals))
+	for t := range gamma {
+		for s, g := range gamma[t] {
+			if h.group[s] == hmmCore {
+				post[t] += g
+			}
+		}
+	}
+	return post
+}
+
+// meanVariance is the weighted mean and variance (at least varFloor) of vals, unweighted if weights is nil
+func meanVariance(vals, weights []float64) (float64, float64) {
+	total, sum := 0.0, 0.0
+	for i, v := range vals {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		total += w
+		sum += w * v
+	}
+	if total == 0 {
+		return 0, varFloor
+	}
+	mean := sum / total
+	ss := 0.0
+	for i, v := range vals {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		ss += w * (v - mean) * (v - mean)
+	}
+	return mean, math.Max(ss/total, varFloor)
+}
+
+// clampStay keeps the chance of continuing a block within [hmmMinStay, 1-hmmMinStay]
+func clampStay(p float64) float64 {
+	return math.Min(math.Max(p, hmmMinStay), 1-hmmMinStay)
+}
@@ -0,0 +1,101 @@
+package windows_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+// coreWithNoise is n values of 0 with a core [start, stop) of 1, plus Gaussian noise of the given standard deviation
+func coreWithNoise(n, start, stop int, sd float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	vals := make([]float64, n)
+	for i := range vals {
+		if start <= i && i < stop {
+			vals[i] = 1
+		}
+		vals[i] += sd * rng.NormFloat64()
+	}
+	return vals
+}
+
+func TestHMM(t *testing.T) {
+	const minWin = 10
+	tt := []struct {
+		name        string
+		vals        []float64
+		start, stop int
+		exp         windows.Window
+	}{
+		{"clean core", coreWithNoise(90, 30, 55, 0, 1), 0, 90, windows.New(30, 55)},
+		{"noisy core", coreWithNoise(90, 30, 55, 0.1, 1), 0, 90, windows.New(30, 55)},
+		{"offset UCE", coreWithNoise(100, 40, 70, 0.1, 2), 10, 100, windows.New(40, 70)},
+		{"core at minWin", coreWithNoise(60, 10, 20, 0.1, 3), 0, 60, windows.New(10, 20)},
+		{"too short", coreWithNoise(25, 10, 15, 0.1, 4), 0, 25, windows.New(0, 25)},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.vals}
+		got := windows.HMM(mets, tc.start, tc.stop, windows.Uniform(minWin), windows.SSE)[metrics.GC]
+		if got.Window != tc.exp {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got.Window, tc.exp)
+		}
+		if got.Segments != nil {
+			t.Errorf("%s: Got segments %v, Expected nil", tc.name, got.Segments)
+		}
+	}
+}
+
+func TestHMMPosterior(t *testing.T) {
+	const (
+		n, minWin = 90, 10
+		start     = 30
+		stop      = 55
+	)
+	mets := map[metrics.Metric][]float64{metrics.GC: coreWithNoise(n, start, stop, 0.1, 1)}
+	got := windows.HMM(mets, 0, n, windows.Uniform(minWin), windows.SSE)[metrics.GC]
+	if len(got.Posterior) != n {
+		t.Fatalf("Got %d posterior probabilities, Expected %d", len(got.Posterior), n)
+	}
+	for i, p := range got.Posterior {
+		if p < 0 || 1 < p {
+			t.Errorf("Site %d: Got posterior %v, Expected within [0, 1]", i, p)
+		}
+		// Away from the boundaries, sites are clearly in or out of the core
+		if start+2 <= i && i < stop-2 && p < 0.99 {
+			t.Errorf("Site %d: Got core posterior %v, Expected at least 0.99", i, p)
+		}
+		if (i < start-2 || stop+2 <= i) && 0.01 < p {
+			t.Errorf("Site %d: Got flank posterior %v, Expected at most 0.01", i, p)
+		}
+	}
+
+	// A UCE too short for three blocks has no posterior
+	short := windows.HMM(mets, 0, 2*minWin, windows.Uniform(minWin), windows.SSE)[metrics.GC]
+	if short.Posterior != nil {
+		t.Errorf("Short UCE: Got posterior %v, Expected nil", short.Posterior)
+	}
+}
+
+func TestHMMSizes(t *testing.T) {
+	const n = 90
+	tt := []struct {
+		name  string
+		vals  []float64
+		sizes windows.Sizes
+	}{
+		// The core is shorter than minWin so the HMM must stretch it or the flanks
+		{"core below minimum", coreWithNoise(n, 40, 47, 0.05, 5), windows.Uniform(15)},
+		// The core is longer than allowed so the HMM must cut it
+		{"core above maximum", coreWithNoise(n, 20, 70, 0.05, 6), windows.Sizes{MinCore: 10, MinFlank: 5, MaxCore: 30}},
+		{"short flanks", coreWithNoise(n, 3, 85, 0.05, 7), windows.Sizes{MinCore: 40, MinFlank: 3}},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.vals}
+		got := windows.HMM(mets, 0, n, tc.sizes, windows.SSE)[metrics.GC].Window
+		if !tc.sizes.Fits(got, 0, n) {
+			t.Errorf("%s: Got %v, Expected blocks fitting %+v", tc.name, got, tc.sizes)
+		}
+	}
+}
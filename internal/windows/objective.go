@@ -0,0 +1,100 @@
+package windows
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Objective is the function of a block's metric values minimised over the flanks and core of a window
+// Every Objective is zero for an empty block and finite for zero-mean or constant blocks
+type Objective int
+
+const (
+	// SSE is the sum of squared errors around the block mean
+	SSE Objective = iota
+
+	// NormSSE is the sum of squared errors around the block mean, each normalised by the mean
+	// A zero-mean block cannot be normalised and uses SSE
+	NormSSE
+
+	// SAD is the sum of absolute deviations around the block mean
+	// Unlike the others it cannot be computed from prefix sums, so each block takes time linear in its length
+	SAD
+
+	// GaussLL is the negative log-likelihood of the block under a Gaussian fit by maximum likelihood
+	// The fitted variance is at least varFloor so constant blocks are not infinitely likely
+	GaussLL
+)
+
+// varFloor is the smallest variance used by GaussLL
+const varFloor = 1e-6
+
+// objectives are all known Objectives
+var objectives = []Objective{SSE, NormSSE, SAD, GaussLL}
+
+func (o Objective) String() string {
+	switch o {
+	case SSE:
+		return "sse"
+	case NormSSE:
+		return "normsse"
+	case SAD:
+		return "sad"
+	case GaussLL:
+		return "gaussll"
+	default:
+		return ""
+	}
+}
+
+// ParseObjective converts the name of an objective to its Objective
+func ParseObjective(name string) (Objective, error) {
+	for _, o := range objectives {
+		if strings.EqualFold(name, o.String()) {
+			return o, nil
+		}
+	}
+	names := make([]string, len(objectives))
+	for i, o := range objectives {
+		names[i] = o.String()
+	}
+	return 0, fmt.Errorf("unknown objective %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// block is the objective value of the block [i, j) in alignment positions
+// All objectives except SAD are computed in constant time from the prefix sums
+func (p *prefix) block(i, j int, obj Objective) float64 {
+	if j <= i {
+		return 0
+	}
+	n := float64(j - i)
+	sum := p.sum[j-p.start] - p.sum[i-p.start]
+	sumSq := p.sumSq[j-p.start] - p.sumSq[i-p.start]
+	mean := sum / n
+
+	// Sum of squares around the mean, rounding error can leave a constant block slightly non-zero
+	ss := sumSq - sum*mean
+	if ss < sumSq*1e-12 {
+		ss = 0
+	}
+
+	switch obj {
+	case NormSSE:
+		if mean == 0 {
+			return ss
+		}
+		return ss / (mean * mean)
+	case SAD:
+		total := 0.0
+		for _, v := range p.vals[i-p.start : j-p.start] {
+			total += math.Abs(v - mean)
+		}
+		return total
+	case GaussLL:
+		variance := math.Max(ss/n, varFloor)
+		return n/2*math.Log(2*math.Pi*variance) + ss/(2*variance)
+	default:
+		return ss
+	}
+}
@@ -0,0 +1,116 @@
+package windows_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestParseObjective(t *testing.T) {
+	tt := []struct {
+		name  string
+		exp   windows.Objective
+		valid bool
+	}{
+		{"sse", windows.SSE, true},
+		{"normsse", windows.NormSSE, true},
+		{"sad", windows.SAD, true},
+		{"gaussll", windows.GaussLL, true},
+		{"SSE", windows.SSE, true},
+		{"mse", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := windows.ParseObjective(tc.name)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseObjective(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.name, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseObjective(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
+
+// gaussNll is the Gaussian negative log-likelihood of n values with the given sum of squares around their mean
+func gaussNll(n, ss float64) float64 {
+	variance := math.Max(ss/n, 1e-6)
+	return n/2*math.Log(2*math.Pi*variance) + ss/(2*variance)
+}
+
+func TestObjective(t *testing.T) {
+	tt := []struct {
+		name  string
+		block []float64
+		exp   map[windows.Objective]float64
+	}{
+		{
+			"All zero (e.g. an all-AT region under GC)",
+			[]float64{0, 0, 0, 0},
+			map[windows.Objective]float64{
+				windows.SSE:     0,
+				windows.NormSSE: 0,
+				windows.SAD:     0,
+				windows.GaussLL: gaussNll(4, 0),
+			},
+		},
+		{
+			"Constant",
+			[]float64{0.3, 0.3, 0.3, 0.3, 0.3},
+			map[windows.Objective]float64{
+				windows.SSE:     0,
+				windows.NormSSE: 0,
+				windows.SAD:     0,
+				windows.GaussLL: gaussNll(5, 0),
+			},
+		},
+		{
+			"Zero mean, not constant",
+			[]float64{-1, 1, -1, 1},
+			map[windows.Objective]float64{
+				windows.SSE:     4,
+				windows.NormSSE: 4,
+				windows.SAD:     4,
+				windows.GaussLL: gaussNll(4, 4),
+			},
+		},
+		{
+			"Increasing",
+			[]float64{1, 2, 3},
+			map[windows.Objective]float64{
+				windows.SSE:     2,
+				windows.NormSSE: 0.5,
+				windows.SAD:     2,
+				windows.GaussLL: gaussNll(3, 2),
+			},
+		},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.block}
+		// A window covering the whole UCE has empty flanks, which score zero
+		win := windows.New(0, len(tc.block))
+		for obj, exp := range tc.exp {
+			got := windows.Score(mets, win, 0, len(tc.block), obj)[metrics.GC]
+			if math.IsNaN(got) || math.IsInf(got, 0) {
+				t.Errorf("%s, %s: Got non-finite %v", tc.name, obj, got)
+			}
+			if !floats.EqualWithinAbs(got, exp, 1e-10) {
+				t.Errorf("%s, %s: Got %v, expected %v", tc.name, obj, got, exp)
+			}
+		}
+	}
+}
+
+// TestObjectiveZeroMetric checks a best window is found for every objective when a metric is all zero
+func TestObjectiveZeroMetric(t *testing.T) {
+	mets := map[metrics.Metric][]float64{metrics.GC: make([]float64, 30)}
+	wins := windows.GenerateWindows(30, windows.Uniform(5))
+	for _, obj := range []windows.Objective{windows.SSE, windows.NormSSE, windows.SAD, windows.GaussLL} {
+		got := windows.GetBest(mets, wins, 0, 30, windows.Ranking{Objective: obj})
+		if _, ok := got[metrics.GC]; !ok {
+			t.Errorf("%s: no best window found for an all zero metric", obj)
+		}
+	}
+}
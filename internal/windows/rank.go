@@ -0,0 +1,180 @@
+package windows
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+)
+
+// DefaultTol is the default tolerance within which objective values or variances are equal
+const DefaultTol = 1e-10
+
+// Ranking orders windows lexicographically by:
+//  1. objective value, lowest first
+//  2. variance in length of the flanks and core, lowest first
+//  3. core size, largest first if LargeCore otherwise smallest first
+//  4. start position, lowest first
+//
+// Values within a tolerance of the best value in their group are equal, so the order is reproducible
+type Ranking struct {
+	Objective Objective // Function minimised over the flanks and core
+	LargeCore bool      // When windows are equivalent, choose the larger core
+	ObjTol    float64   // Objective values within ObjTol are tied
+	VarTol    float64   // Variances within VarTol are tied
+}
+
+// Best is the best window of a metric within a UCE
+// A segmentation algorithm instead partitions the UCE into Segments, Window is then the whole UCE
+type Best struct {
+	Window    Window    // Best window
+	Segments  []Window  // Consecutive segments covering the UCE, nil unless found by segmentation
+	Posterior []float64 // Probability each site of the UCE is in the core, nil unless found by an HMM
+	Score     float64   // Objective value of Window (or the summed value of Segments)
+	Ties      int       // Number of windows tied with Window on objective value, including Window
+	Status    Status    // Whether the UCE is partitioned as found, or why it is used whole
+}
+
+// ranked is a window with the values it is ranked by
+type ranked struct {
+	win      Window
+	score    float64
+	variance float64
+}
+
+// newRanked scores each window for each metric within the UCE [start, stop)
+func newRanked(mets map[metrics.Metric][]float64, wins []Window, start, stop int, obj Objective) map[metrics.Metric][]ranked {
+	rs := make(map[metrics.Metric][]ranked, len(mets))
+	for m := range mets {
+		rs[m] = make([]ranked, len(wins))
+	}
+	pres := newPrefixes(mets, start, stop)
+	for i, win := range wins {
+		for m, p := range pres {
+			rs[m][i] = rankWindow(p, win, start, stop, obj)
+		}
+	}
+	return rs
+}
+
+// rankWindow scores a single window of a metric within the UCE [start, stop)
+func rankWindow(p *prefix, win Window, start, stop int, obj Objective) ranked {
+	v := p.getSse(win, obj)
+	if math.IsNaN(v) { // An undefined objective is never better than a defined one
+		v = math.Inf(1)
+	}
+	return ranked{win, v, winVariance(win, start, stop)}
+}
+
+// order sorts windows best first, removes repeated windows, and returns the remaining windows
+// along with the number tied with the best on objective value
+func (r Ranking) order(rs []ranked) ([]ranked, int) {
+	if len(rs) == 0 {
+		return rs, 0
+	}
+	// Exact order first so repeated windows are adjacent and groups are formed from their lowest value
+	sort.Slice(rs, func(i, j int) bool { return r.byScore(rs[i], rs[j]) })
+	uniq := rs[:1]
+	for _, w := range rs[1:] {
+		if w.win != uniq[len(uniq)-1].win {
+			uniq = append(uniq, w)
+		}
+	}
+	rs = uniq
+
+	ties := 0
+	for lo := 0; lo < len(rs); {
+		hi := group(rs, lo, r.ObjTol, func(w ranked) float64 { return w.score })
+		if lo == 0 {
+			ties = hi
+		}
+		objGrp := rs[lo:hi]
+		sort.Slice(objGrp, func(i, j int) bool { return r.byVariance(objGrp[i], objGrp[j]) })
+		for vlo := 0; vlo < len(objGrp); {
+			vhi := group(objGrp, vlo, r.VarTol, func(w ranked) float64 { return w.variance })
+			varGrp := objGrp[vlo:vhi]
+			sort.Slice(varGrp, func(i, j int) bool { return r.bySize(varGrp[i], varGrp[j]) })
+			vlo = vhi
+		}
+		lo = hi
+	}
+	return rs, ties
+}
+
+// first is the window order would rank first, along with the number tied with it on objective value
+// each must call its function once with every window, it is called three times rather than holding the windows
+func (r Ranking) first(each func(func(ranked))) (ranked, int) {
+	var (
+		best     ranked
+		score    = math.Inf(1)
+		variance = math.Inf(1)
+		ties     = 0
+	)
+	// Windows are tied with the lowest objective value, then with the lowest variance among those
+	each(func(w ranked) {
+		if w.score < score {
+			score = w.score
+		}
+	})
+	tied := func(v, low, tol float64) bool { return v == low || v-low <= tol }
+	each(func(w ranked) {
+		if tied(w.score, score, r.ObjTol) {
+			ties++
+			if w.variance < variance {
+				variance = w.variance
+			}
+		}
+	})
+	if ties == 0 {
+		return best, 0
+	}
+	found := false
+	each(func(w ranked) {
+		if tied(w.score, score, r.ObjTol) && tied(w.variance, variance, r.VarTol) && (!found || r.bySize(w, best)) {
+			best, found = w, true
+		}
+	})
+	return best, ties
+}
+
+// group is the end of the group starting at lo, all values of which are within tol of the value at lo
+// rs must be sorted by value
+func group(rs []ranked, lo int, tol float64, val func(ranked) float64) int {
+	hi := lo + 1
+	for hi < len(rs) && val(rs[hi])-val(rs[lo]) <= tol {
+		hi++
+	}
+	return hi
+}
+
+// byScore orders by exact objective value, then variance
+func (r Ranking) byScore(a, b ranked) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return r.byVariance(a, b)
+}
+
+// byVariance orders by exact variance, then size
+func (r Ranking) byVariance(a, b ranked) bool {
+	if a.variance != b.variance {
+		return a.variance < b.variance
+	}
+	return r.bySize(a, b)
+}
+
+// bySize orders by user-preference for core size, then position
+func (r Ranking) bySize(a, b ranked) bool {
+	sa := a.win.Stop() - a.win.Start()
+	sb := b.win.Stop() - b.win.Start()
+	switch {
+	case sa != sb && r.LargeCore:
+		return sa > sb
+	case sa != sb:
+		return sa < sb
+	case a.win.Start() != b.win.Start():
+		return a.win.Start() < b.win.Start()
+	default:
+		return a.win.Stop() < b.win.Stop()
+	}
+}
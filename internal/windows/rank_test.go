@@ -0,0 +1,90 @@
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestRanking(t *testing.T) {
+	flat := map[metrics.Metric][]float64{metrics.GC: make([]float64, 8)}
+	step := map[metrics.Metric][]float64{metrics.GC: []float64{0, 0, 0, 1, 1, 0, 0, 0}}
+	tt := []struct {
+		name string
+		mets map[metrics.Metric][]float64
+		wins []windows.Window
+		rank windows.Ranking
+		exp  windows.Window
+		ties int
+	}{
+		{ // Objective decides before variance
+			"objective", step,
+			[]windows.Window{windows.New(2, 5), windows.New(3, 5)},
+			windows.Ranking{},
+			windows.New(3, 5), 1,
+		},
+		{ // Within tolerance, lowest variance (3, 2, 3) beats (2, 2, 4)
+			"variance", flat,
+			[]windows.Window{windows.New(2, 4), windows.New(3, 5)},
+			windows.Ranking{ObjTol: windows.DefaultTol},
+			windows.New(3, 5), 2,
+		},
+		{ // Equal variance (2, 3, 3) and (3, 3, 2) versus (3, 2, 3), smallest core first
+			"small core", flat,
+			[]windows.Window{windows.New(2, 5), windows.New(3, 6), windows.New(3, 5)},
+			windows.Ranking{},
+			windows.New(3, 5), 3,
+		},
+		{
+			"large core", flat,
+			[]windows.Window{windows.New(3, 5), windows.New(3, 6), windows.New(2, 5)},
+			windows.Ranking{LargeCore: true},
+			windows.New(2, 5), 3,
+		},
+		{ // Repeated windows are counted once
+			"repeats", flat,
+			[]windows.Window{windows.New(3, 5), windows.New(3, 5), windows.New(2, 4)},
+			windows.Ranking{},
+			windows.New(3, 5), 2,
+		},
+	}
+	for _, tc := range tt {
+		got := windows.GetBest(tc.mets, tc.wins, 0, 8, tc.rank)[metrics.GC]
+		if got.Window != tc.exp || got.Ties != tc.ties {
+			t.Errorf("%s: Got %v with %d ties, Expected %v with %d ties", tc.name, got.Window, got.Ties, tc.exp, tc.ties)
+		}
+	}
+}
+
+// TestRankingIsReproducible checks the best windows do not depend on the order windows are given in
+func TestRankingIsReproducible(t *testing.T) {
+	mets := map[metrics.Metric][]float64{metrics.GC: make([]float64, 30)}
+	wins := windows.GenerateWindows(30, windows.Uniform(5))
+	rev := make([]windows.Window, len(wins))
+	for i, w := range wins {
+		rev[len(wins)-1-i] = w
+	}
+	rank := windows.Ranking{ObjTol: windows.DefaultTol, VarTol: windows.DefaultTol}
+
+	fwd := windows.GetBestN(mets, wins, 0, 30, rank, 5)[metrics.GC]
+	bwd := windows.GetBestN(mets, rev, 0, 30, rank, 5)[metrics.GC]
+	for i := range fwd {
+		if fwd[i] != bwd[i] {
+			t.Errorf("Rank %d: Got %v in order, %v in reverse order", i, fwd[i], bwd[i])
+		}
+	}
+	if best := windows.GetBest(mets, rev, 0, 30, rank)[metrics.GC]; best.Window != fwd[0] || best.Ties != len(wins) {
+		t.Errorf("Got %v with %d ties, Expected %v with %d ties", best.Window, best.Ties, fwd[0], len(wins))
+	}
+}
+
+// TestGetBestNUsesObjective checks the objective, not window size, decides the best N
+func TestGetBestNUsesObjective(t *testing.T) {
+	mets := map[metrics.Metric][]float64{metrics.GC: []float64{0, 0, 0, 1, 1, 0, 0, 0}}
+	wins := []windows.Window{windows.New(2, 4), windows.New(3, 5), windows.New(2, 6)}
+	got := windows.GetBestN(mets, wins, 0, 8, windows.Ranking{}, 1)[metrics.GC]
+	if len(got) != 1 || got[0] != windows.New(3, 5) {
+		t.Errorf("Got %v, Expected [%v]", got, windows.New(3, 5))
+	}
+}
@@ -0,0 +1,132 @@
+package windows
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+)
+
+// Criterion is the information criterion used to choose how many blocks a UCE is partitioned into
+type Criterion int
+
+const (
+	// NoCriterion always partitions a UCE into left flank, core, and right flank
+	NoCriterion Criterion = iota
+
+	// AIC is the Akaike information criterion, deviance + 2 per parameter
+	AIC
+
+	// BIC is the Bayesian information criterion, deviance + log(sites) per parameter
+	BIC
+)
+
+// criteria are all known Criterions
+var criteria = []Criterion{NoCriterion, AIC, BIC}
+
+func (c Criterion) String() string {
+	switch c {
+	case NoCriterion:
+		return "none"
+	case AIC:
+		return "aic"
+	case BIC:
+		return "bic"
+	default:
+		return ""
+	}
+}
+
+// ParseCriterion converts the name of an information criterion to its Criterion
+func ParseCriterion(name string) (Criterion, error) {
+	for _, c := range criteria {
+		if strings.EqualFold(name, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown criterion %q, expected none, aic, or bic", name)
+}
+
+// Blocks is the number of non-empty blocks (flanks and core) of the window within the UCE [start, stop)
+// A window with empty flanks describes a UCE partitioned into fewer than three blocks
+func (w *Window) Blocks(start, stop int) int {
+	return len(blocks(*w, start, stop))
+}
+
+// SelectScheme chooses, for each metric, between the UCE [start, stop) unsplit, split into two blocks,
+// or split by its best three block window, using the information criterion over the objective.
+// The chosen scheme is returned as a window: the whole UCE when unsplit, or a window with one empty flank when split in two.
+// A two block split is a core and one flank, with either block first, fitting the sizes.
+func SelectScheme(mets map[metrics.Metric][]float64, best map[metrics.Metric]Best, start, stop int, sizes Sizes, rank Ranking, crit Criterion) map[metrics.Metric]Best {
+	if crit == NoCriterion {
+		return best
+	}
+	var splits []Window
+	for b := start + 1; b < stop; b++ {
+		left, right := b-start, stop-b
+		if sizes.MinFlank <= left && sizes.coreFits(right) { // Flank then core
+			splits = append(splits, New(b, stop))
+		}
+		if sizes.coreFits(left) && sizes.MinFlank <= right { // Core then flank
+			splits = append(splits, New(start, b))
+		}
+	}
+	two := GetBest(mets, splits, start, stop, rank)
+	whole := New(start, stop)
+
+	pres := newPrefixes(mets, start, stop)
+	chosen := make(map[metrics.Metric]Best, len(best))
+	for m, three := range best {
+		candidates := []Best{{Window: whole, Score: pres[m].getSse(whole, rank.Objective), Ties: 1}}
+		if t, ok := two[m]; ok {
+			candidates = append(candidates, t)
+		}
+		candidates = append(candidates, three)
+
+		chosen[m] = candidates[0]
+		bestIC := math.Inf(1)
+		for _, c := range candidates { // Fewest blocks first, so fewer blocks win ties
+			ic := crit.score(c.Score, c.Window.Blocks(start, stop), stop-start, rank.Objective)
+			if ic < bestIC {
+				bestIC = ic
+				chosen[m] = c
+			}
+		}
+	}
+	return chosen
+}
+
+// score is the information criterion of a partition into k blocks of n sites with the given objective value
+func (c Criterion) score(obj float64, k, n int, o Objective) float64 {
+	dev, params := deviance(obj, k, n, o)
+	switch c {
+	case AIC:
+		return dev + 2*params
+	case BIC:
+		return dev + params*math.Log(float64(n))
+	default:
+		return dev
+	}
+}
+
+// deviance is -2 log-likelihood (up to a constant) and the number of free parameters of a partition
+// into k blocks of n sites, given its summed objective value
+//
+//	SSE and NormSSE: Gaussian blocks with a shared variance, k means plus one variance
+//	SAD: Laplace blocks with a shared scale, k locations plus one scale
+//	GaussLL: Gaussian blocks each with their own variance, k means plus k variances
+//
+// Every partition also estimates k-1 block boundaries
+func deviance(obj float64, k, n int, o Objective) (float64, float64) {
+	boundaries := float64(k - 1)
+	spread := math.Max(obj/float64(n), varFloor)
+	switch o {
+	case GaussLL:
+		return 2 * obj, float64(2*k) + boundaries
+	case SAD:
+		return 2 * float64(n) * math.Log(spread), float64(k+1) + boundaries
+	default:
+		return float64(n) * math.Log(spread), float64(k+1) + boundaries
+	}
+}
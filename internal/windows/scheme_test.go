@@ -0,0 +1,125 @@
+package windows_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestParseCriterion(t *testing.T) {
+	tt := []struct {
+		name  string
+		exp   windows.Criterion
+		valid bool
+	}{
+		{"none", windows.NoCriterion, true},
+		{"aic", windows.AIC, true},
+		{"BIC", windows.BIC, true},
+		{"aicc", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tt {
+		got, err := windows.ParseCriterion(tc.name)
+		if tc.valid && (err != nil || got != tc.exp) {
+			t.Errorf("ParseCriterion(%q) => Got: (%v, %v), Expected: (%v, nil)", tc.name, got, err, tc.exp)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("ParseCriterion(%q) => Got: nil error, Expected: !nil", tc.name)
+		}
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	tt := []struct {
+		win windows.Window
+		exp int
+	}{
+		{windows.New(0, 30), 1},
+		{windows.New(10, 30), 2},
+		{windows.New(0, 20), 2},
+		{windows.New(10, 20), 3},
+	}
+	for _, tc := range tt {
+		if got := tc.win.Blocks(0, 30); got != tc.exp {
+			t.Errorf("%v => Got: %d blocks, Expected: %d", tc.win, got, tc.exp)
+		}
+	}
+}
+
+// steps is a metric of n sites taking a different value (0, 1, 2, ...) at each boundary
+func steps(n int, boundaries ...int) []float64 {
+	vals := make([]float64, n)
+	for i := range vals {
+		for _, b := range boundaries {
+			if b <= i {
+				vals[i]++
+			}
+		}
+	}
+	return vals
+}
+
+func TestSelectScheme(t *testing.T) {
+	const (
+		n      = 60
+		minWin = 10
+	)
+	rank := windows.Ranking{ObjTol: windows.DefaultTol}
+	tt := []struct {
+		name string
+		vals []float64
+		exp  int
+	}{
+		{"homogeneous", steps(n), 1},
+		{"two blocks", steps(n, 25), 2},
+		{"three blocks", steps(n, 20, 40), 3},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.vals}
+		for _, obj := range []windows.Objective{windows.SSE, windows.SAD, windows.GaussLL} {
+			rank.Objective = obj
+			best := windows.GetBest(mets, windows.GenerateWindows(n, windows.Uniform(minWin)), 0, n, rank)
+			for _, crit := range []windows.Criterion{windows.AIC, windows.BIC} {
+				got := windows.SelectScheme(mets, best, 0, n, windows.Uniform(minWin), rank, crit)[metrics.GC]
+				if blocks := got.Window.Blocks(0, n); blocks != tc.exp {
+					t.Errorf("%s, %s, %s: Got %d blocks (%v), Expected %d", tc.name, crit, obj, blocks, got.Window, tc.exp)
+				}
+			}
+			got := windows.SelectScheme(mets, best, 0, n, windows.Uniform(minWin), rank, windows.NoCriterion)[metrics.GC]
+			if !reflect.DeepEqual(got, best[metrics.GC]) {
+				t.Errorf("%s, none, %s: Got %v, Expected the three block window %v", tc.name, obj, got, best[metrics.GC])
+			}
+		}
+	}
+}
+
+// TestSelectSchemeSizes checks a two block split is a core and one flank of allowed lengths
+func TestSelectSchemeSizes(t *testing.T) {
+	const n = 60
+	rank := windows.Ranking{ObjTol: windows.DefaultTol}
+	mets := map[metrics.Metric][]float64{metrics.GC: steps(n, 52)}
+	tt := []struct {
+		name    string
+		sizes   windows.Sizes
+		allowed bool // Whether the split at the step may be used
+	}{
+		{"flank too short", windows.Uniform(15), false},
+		{"short flank allowed", windows.Sizes{MinCore: 30, MinFlank: 5}, true},
+		{"core too long", windows.Sizes{MinCore: 30, MinFlank: 5, MaxCore: 40}, false},
+	}
+	for _, tc := range tt {
+		best := windows.GetBest(mets, windows.GenerateWindows(n, tc.sizes), 0, n, rank)
+		got := windows.SelectScheme(mets, best, 0, n, tc.sizes, rank, windows.BIC)[metrics.GC].Window
+		if split := windows.New(0, 52); (got == split) != tc.allowed { // The core, then a short flank
+			t.Errorf("%s: Got %v, Expected split at the step %t", tc.name, got, tc.allowed)
+		}
+		if got.Blocks(0, n) == 2 {
+			core := got.Stop() - got.Start()
+			if core < tc.sizes.MinCore || (tc.sizes.MaxCore != 0 && tc.sizes.MaxCore < core) || n-core < tc.sizes.MinFlank {
+				t.Errorf("%s: Got %v, Expected a core and flank fitting %+v", tc.name, got, tc.sizes)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package windows
+
+import (
+	"math"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+)
+
+// Segment finds, for each metric, the partition of the UCE [start, stop) into k consecutive segments,
+// each at least minWin long, with the lowest summed objective value.
+// If k is zero, k is chosen by crit or, without a criterion, by the lowest objective value plus penalty per segment.
+// A penalty of zero uses DefaultPenalty.
+// Fewer segments are used if the UCE cannot hold k segments of minWin.
+// Run time is O(k*n^2) block scores for a UCE of n sites, where k is up to n/minWin when chosen.
+func Segment(mets map[metrics.Metric][]float64, start, stop, minWin, k int, obj Objective, crit Criterion, penalty float64) map[metrics.Metric]Best {
+	n := stop - start
+	if minWin < 1 {
+		minWin = 1
+	}
+	maxK := k
+	if k == 0 {
+		maxK = n / minWin
+	}
+	if n/minWin < maxK {
+		maxK = n / minWin
+	}
+	if maxK < 1 {
+		maxK = 1
+	}
+
+	best := make(map[metrics.Metric]Best, len(mets))
+	for m, p := range newPrefixes(mets, start, stop) {
+		cost, back := p.segment(minWin, maxK, obj)
+		chosen := maxK
+		if k == 0 {
+			pen := penalty
+			if pen == 0 {
+				pen = p.defaultPenalty(obj)
+			}
+			bestIC := math.Inf(1)
+			for j := 1; j <= maxK; j++ { // Fewest segments first, so fewer segments win ties
+				ic := cost[j][n] + float64(j)*pen
+				if crit != NoCriterion {
+					ic = crit.score(cost[j][n], j, n, obj)
+				}
+				if ic < bestIC {
+					bestIC = ic
+					chosen = j
+				}
+			}
+		}
+		// Walk back from the end of the UCE to recover the segment boundaries
+		segs := make([]Window, chosen)
+		j := n
+		for i := chosen; 0 < i; i-- {
+			from := back[i][j]
+			segs[i-1] = New(start+from, start+j)
+			j = from
+		}
+		best[m] = Best{
+			Window:   New(start, stop),
+			Segments: segs,
+			Score:    cost[chosen][n],
+			Ties:     1,
+		}
+	}
+	return best
+}
+
+// DefaultPenalty is a BIC-style cost of one more segment of the UCE [start, stop), in units of the objective
+// The noise level is the variance of the whole UCE, which overstates noise when segments differ and so errs towards fewer segments
+func DefaultPenalty(vals []float64, start, stop int, obj Objective) float64 {
+	return newPrefix(vals, start, stop).defaultPenalty(obj)
+}
+
+// defaultPenalty charges log(n) per parameter (a mean and a boundary, plus a variance for GaussLL) on the -2 log-likelihood scale
+func (p *prefix) defaultPenalty(obj Objective) float64 {
+	n := len(p.vals)
+	if n < 2 {
+		return 0
+	}
+	variance := math.Max(p.block(p.start, p.start+n, SSE)/float64(n), varFloor)
+	logN := math.Log(float64(n))
+
+	switch obj {
+	case NormSSE:
+		mean := p.sum[n] / float64(n)
+		if mean == 0 {
+			return 2 * logN * variance
+		}
+		return 2 * logN * variance / (mean * mean)
+	case SAD:
+		return logN * math.Sqrt(variance/2) // Laplace scale matching the variance
+	case GaussLL:
+		return 1.5 * logN
+	default:
+		return 2 * logN * variance
+	}
+}
+
+// segment fills the dynamic programming tables for splitting the UCE into up to maxK segments of at least minWin.
+// cost[k][j] is the lowest objective value of the first j sites split into k segments,
+// back[k][j] is where the last of those segments begins (relative to the UCE start).
+// Unreachable cells are +Inf. If the UCE is shorter than minWin it is a single segment.
+func (p *prefix) segment(minWin, maxK int, obj Objective) ([][]float64, [][]int) {
+	n := len(p.vals)
+	cost := make([][]float64, maxK+1)
+	back := make([][]int, maxK+1)
+	for k := range cost {
+		cost[k] = make([]float64, n+1)
+		back[k] = make([]int, n+1)
+		for j := range cost[k] {
+			cost[k][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+	if n < minWin {
+		cost[1][n] = p.block(p.start, p.start+n, obj)
+		return cost, back
+	}
+	for k := 1; k <= maxK; k++ {
+		for j := k * minWin; j <= n; j++ {
+			for i := (k - 1) * minWin; i <= j-minWin; i++ {
+				if math.IsInf(cost[k-1][i], 1) {
+					continue
+				}
+				c := cost[k-1][i] + p.block(p.start+i, p.start+j, obj)
+				if c < cost[k][j] {
+					cost[k][j] = c
+					back[k][j] = i
+				}
+			}
+		}
+	}
+	return cost, back
+}
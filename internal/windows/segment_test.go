@@ -0,0 +1,109 @@
+package windows_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestSegment(t *testing.T) {
+	const minWin = 10
+	tt := []struct {
+		name string
+		vals []float64
+		k    int
+		crit windows.Criterion
+		pen  float64
+		exp  []windows.Window
+	}{
+		{
+			"fixed k", steps(80, 20, 50, 65), 4, windows.NoCriterion, 0,
+			[]windows.Window{windows.New(0, 20), windows.New(20, 50), windows.New(50, 65), windows.New(65, 80)},
+		},
+		{
+			"chosen k", steps(80, 20, 50, 65), 0, windows.BIC, 0,
+			[]windows.Window{windows.New(0, 20), windows.New(20, 50), windows.New(50, 65), windows.New(65, 80)},
+		},
+		{
+			"homogeneous", steps(80), 0, windows.AIC, 0,
+			[]windows.Window{windows.New(0, 80)},
+		},
+		{
+			"penalised k", steps(80, 20, 50, 65), 0, windows.NoCriterion, 1,
+			[]windows.Window{windows.New(0, 20), windows.New(20, 50), windows.New(50, 65), windows.New(65, 80)},
+		},
+		{
+			"default penalty", steps(80, 40), 0, windows.NoCriterion, 0,
+			[]windows.Window{windows.New(0, 40), windows.New(40, 80)},
+		},
+		{
+			"large penalty", steps(80, 20, 50, 65), 0, windows.NoCriterion, 1000,
+			[]windows.Window{windows.New(0, 80)},
+		},
+		{
+			"homogeneous penalised", steps(80), 0, windows.NoCriterion, 0,
+			[]windows.Window{windows.New(0, 80)},
+		},
+		{
+			"too short for k", steps(25, 12), 4, windows.NoCriterion, 0,
+			[]windows.Window{windows.New(0, 12), windows.New(12, 25)},
+		},
+		{
+			"shorter than minWin", steps(5), 3, windows.NoCriterion, 0,
+			[]windows.Window{windows.New(0, 5)},
+		},
+	}
+	for _, tc := range tt {
+		mets := map[metrics.Metric][]float64{metrics.GC: tc.vals}
+		got := windows.Segment(mets, 0, len(tc.vals), minWin, tc.k, windows.SSE, tc.crit, tc.pen)[metrics.GC]
+		if len(got.Segments) != len(tc.exp) {
+			t.Errorf("%s: Got %v, Expected %v", tc.name, got.Segments, tc.exp)
+			continue
+		}
+		for i := range tc.exp {
+			if got.Segments[i] != tc.exp[i] {
+				t.Errorf("%s: Got %v, Expected %v", tc.name, got.Segments, tc.exp)
+				break
+			}
+		}
+	}
+}
+
+// TestSegmentMatchesExhaustive checks three segments are as good as the exhaustive window search, away from the alignment start
+func TestSegmentMatchesExhaustive(t *testing.T) {
+	const (
+		start, stop = 7, 67
+		minWin      = 10
+	)
+	vals := make([]float64, stop)
+	for i := range vals {
+		vals[i] = math.Sin(float64(i) / 5)
+	}
+	mets := map[metrics.Metric][]float64{metrics.Entropy: vals}
+	for _, obj := range []windows.Objective{windows.SSE, windows.SAD, windows.GaussLL} {
+		wins := windows.GenerateWindows(stop-start, windows.Uniform(minWin))
+		for i, w := range wins {
+			wins[i] = windows.New(w.Start()+start, w.Stop()+start)
+		}
+		exp := windows.GetBest(mets, wins, start, stop, windows.Ranking{Objective: obj})[metrics.Entropy]
+		got := windows.Segment(mets, start, stop, minWin, 3, obj, windows.NoCriterion, 0)[metrics.Entropy]
+		if 1e-9 < math.Abs(got.Score-exp.Score) {
+			t.Errorf("%s: Got score %v (%v), Expected %v (%v)", obj, got.Score, got.Segments, exp.Score, exp.Window)
+		}
+		for _, s := range got.Segments {
+			if s.Stop()-s.Start() < minWin || s.Start() < start || stop < s.Stop() {
+				t.Errorf("%s: Segment %v is shorter than %d or outside [%d, %d)", obj, s, minWin, start, stop)
+			}
+		}
+	}
+}
+
+func TestDefaultPenalty(t *testing.T) {
+	for _, obj := range []windows.Objective{windows.SSE, windows.NormSSE, windows.SAD, windows.GaussLL} {
+		if pen := windows.DefaultPenalty(make([]float64, 50), 0, 50, obj); pen <= 0 || math.IsNaN(pen) || math.IsInf(pen, 0) {
+			t.Errorf("%s: Got penalty %v for a constant UCE, Expected positive and finite", obj, pen)
+		}
+	}
+}
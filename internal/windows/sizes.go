@@ -0,0 +1,40 @@
+package windows
+
+// Sizes bound the length, in sites, of the core and flanks of a window
+type Sizes struct {
+	MinCore  int // Shortest core
+	MinFlank int // Shortest left and right flank
+	MaxCore  int // Longest core, zero for no limit
+}
+
+// Uniform is the Sizes where the core and both flanks are at least minWin, with no longest core
+func Uniform(minWin int) Sizes {
+	return Sizes{MinCore: minWin, MinFlank: minWin}
+}
+
+// MinLength is the shortest UCE that holds a left flank, core, and right flank
+func (s Sizes) MinLength() int {
+	return s.MinCore + 2*s.MinFlank
+}
+
+// MinBlock is the shortest of the core and flanks, the shortest block when blocks are not labelled (at least one)
+func (s Sizes) MinBlock() int {
+	min := s.MinCore
+	if s.MinFlank < min {
+		min = s.MinFlank
+	}
+	if min < 1 {
+		min = 1
+	}
+	return min
+}
+
+// Fits checks the window has a core and flanks of allowed length within the UCE [start, stop)
+func (s Sizes) Fits(w Window, start, stop int) bool {
+	return s.MinFlank <= w.Start()-start && s.MinFlank <= stop-w.Stop() && s.coreFits(w.Stop()-w.Start())
+}
+
+// coreFits checks a core of n sites is neither too short nor too long
+func (s Sizes) coreFits(n int) bool {
+	return s.MinCore <= n && (s.MaxCore == 0 || n <= s.MaxCore)
+}
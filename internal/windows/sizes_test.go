@@ -0,0 +1,95 @@
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestSizesFits(t *testing.T) {
+	sizes := windows.Sizes{MinCore: 20, MinFlank: 5, MaxCore: 30}
+	tt := []struct {
+		win windows.Window
+		exp bool
+	}{
+		{windows.New(5, 25), true},
+		{windows.New(5, 35), true},
+		{windows.New(4, 25), false},  // Left flank too short
+		{windows.New(30, 50), true},  // Right flank at minimum
+		{windows.New(31, 51), false}, // Right flank too short
+		{windows.New(10, 29), false}, // Core too short
+		{windows.New(10, 41), false}, // Core too long
+	}
+	for _, tc := range tt {
+		if got := sizes.Fits(tc.win, 0, 55); got != tc.exp {
+			t.Errorf("Fits(%v) => Got: %t, Expected: %t", tc.win, got, tc.exp)
+		}
+	}
+	if got := (windows.Sizes{MinCore: 20, MinFlank: 5}).Fits(windows.New(5, 50), 0, 55); !got {
+		t.Errorf("Fits without a longest core => Got: %t, Expected: true", got)
+	}
+}
+
+func TestSizesLengths(t *testing.T) {
+	tt := []struct {
+		sizes     windows.Sizes
+		minLength int
+		minBlock  int
+	}{
+		{windows.Uniform(50), 150, 50},
+		{windows.Sizes{MinCore: 100, MinFlank: 10}, 120, 10},
+		{windows.Sizes{MinCore: 5, MinFlank: 10}, 25, 5},
+		{windows.Sizes{}, 0, 1},
+	}
+	for _, tc := range tt {
+		if got := tc.sizes.MinLength(); got != tc.minLength {
+			t.Errorf("%+v MinLength() => Got: %d, Expected: %d", tc.sizes, got, tc.minLength)
+		}
+		if got := tc.sizes.MinBlock(); got != tc.minBlock {
+			t.Errorf("%+v MinBlock() => Got: %d, Expected: %d", tc.sizes, got, tc.minBlock)
+		}
+	}
+}
+
+// TestGeneratorsFitSizes checks every generated window fits the sizes, and that GenerateWindows finds all of them
+func TestGeneratorsFitSizes(t *testing.T) {
+	const start, stop = 3, 83
+	for _, sizes := range []windows.Sizes{
+		windows.Uniform(10),
+		{MinCore: 20, MinFlank: 4},
+		{MinCore: 8, MinFlank: 15, MaxCore: 25},
+		{MinCore: 30, MinFlank: 2, MaxCore: 30},
+	} {
+		all := 0
+		for l := start; l < stop; l++ {
+			for r := l + 1; r <= stop; r++ {
+				if sizes.Fits(windows.New(l, r), start, stop) {
+					all++
+				}
+			}
+		}
+		wins := windows.GenerateWindows(stop-start, sizes)
+		if len(wins) != all {
+			t.Errorf("%+v: GenerateWindows gave %d windows, Expected %d", sizes, len(wins), all)
+		}
+		for _, w := range wins {
+			if !sizes.Fits(w, 0, stop-start) {
+				t.Errorf("%+v: GenerateWindows gave %v", sizes, w)
+			}
+		}
+		cans := windows.GenerateCandidates(start, stop, sizes)
+		if len(cans) == 0 {
+			t.Errorf("%+v: GenerateCandidates gave no candidates", sizes)
+		}
+		for _, c := range cans {
+			if !sizes.Fits(c, start, stop) {
+				t.Errorf("%+v: GenerateCandidates gave %v", sizes, c)
+			}
+			for _, w := range windows.ExtendCandidate(c, start, stop, sizes) {
+				if !sizes.Fits(w, start, stop) {
+					t.Errorf("%+v: ExtendCandidate(%v) gave %v", sizes, c, w)
+				}
+			}
+		}
+	}
+}
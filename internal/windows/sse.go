@@ -1,34 +1,61 @@
 package windows
 
 import (
-	"math"
-
 	"github.com/rhagenson/swsc/internal/metrics"
-	"gonum.org/v1/gonum/stat"
 )
 
-// Compute Sum of Square Errors
-func sse(vs []float64) float64 {
-	mean := stat.Mean(vs, nil)
-	total := 0.0
-	for _, v := range vs {
-		total += math.Pow((v / mean), 2)
+// prefix holds the cumulative sums of a metric over a UCE so any block can be scored in constant time
+type prefix struct {
+	start int       // Alignment position of the UCE start
+	vals  []float64 // Metric values of the UCE
+	sum   []float64 // sum[i] is the sum of the first i values of the UCE
+	sumSq []float64 // sumSq[i] is the sum of squares of the first i values of the UCE
+}
+
+// newPrefix precomputes the cumulative sums of metric over the UCE [start, stop)
+func newPrefix(metric []float64, start, stop int) *prefix {
+	p := &prefix{
+		start: start,
+		vals:  metric[start:stop],
+		sum:   make([]float64, stop-start+1),
+		sumSq: make([]float64, stop-start+1),
 	}
-	return total
+	for i, v := range p.vals {
+		p.sum[i+1] = p.sum[i] + v
+		p.sumSq[i+1] = p.sumSq[i] + v*v
+	}
+	return p
 }
 
-func getSse(metric []float64, win Window) float64 {
-	left := sse(metric[:win.Start()])
-	core := sse(metric[win.Start():win.Stop()])
-	right := sse(metric[win.Stop():])
+// newPrefixes precomputes the cumulative sums of each metric over the UCE [start, stop)
+func newPrefixes(mets map[metrics.Metric][]float64, start, stop int) map[metrics.Metric]*prefix {
+	pres := make(map[metrics.Metric]*prefix, len(mets))
+	for m, v := range mets {
+		pres[m] = newPrefix(v, start, stop)
+	}
+	return pres
+}
+
+// getSse is the objective value of the left flank, core, and right flank of a window within the UCE
+// Only sites of the UCE are considered so a UCE's score is unaffected by its neighbours
+func (p *prefix) getSse(win Window, obj Objective) float64 {
+	stop := p.start + len(p.vals)
+	left := p.block(p.start, win.Start(), obj)
+	core := p.block(win.Start(), win.Stop(), obj)
+	right := p.block(win.Stop(), stop, obj)
 	return left + core + right
 }
 
 // getSses generalized getSse over each site window.
-func getSses(mets map[metrics.Metric][]float64, win Window) map[metrics.Metric]float64 {
-	sses := make(map[metrics.Metric]float64, len(mets))
-	for m := range mets {
-		sses[m] = getSse(mets[m], win)
+func getSses(pres map[metrics.Metric]*prefix, win Window, obj Objective) map[metrics.Metric]float64 {
+	sses := make(map[metrics.Metric]float64, len(pres))
+	for m, p := range pres {
+		sses[m] = p.getSse(win, obj)
 	}
 	return sses
 }
+
+// Score is the objective value of a window for each metric within the UCE [start, stop)
+func Score(mets map[metrics.Metric][]float64, win Window, start, stop int, obj Objective) map[metrics.Metric]float64 {
+	return getSses(newPrefixes(mets, start, stop), win, obj)
+}
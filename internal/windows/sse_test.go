@@ -1 +1,92 @@
 package windows_test
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/windows"
+	"gonum.org/v1/gonum/floats"
+)
+
+// candidateWindows are the windows the heuristic search scores for a UCE [start, stop)
+func candidateWindows(start, stop, minWin int) []windows.Window {
+	wins := windows.GenerateCandidates(start, stop, windows.Uniform(minWin))
+	for _, w := range windows.GenerateCandidates(start, stop, windows.Uniform(minWin))[:3] {
+		wins = append(wins, windows.ExtendCandidate(w, start, stop, windows.Uniform(minWin))...)
+	}
+	return wins
+}
+
+func TestScorePrefix(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	metric := make([]float64, 1000)
+	for i := range metric {
+		metric[i] = rng.Float64()
+	}
+	start, stop := 100, 900
+	wins := candidateWindows(start, stop, 50)
+
+	naive := windows.ScoreNaive(metric, wins, start, stop)
+	prefix := windows.ScorePrefix(metric, wins, start, stop)
+	for i := range wins {
+		if !floats.EqualWithinRel(naive[i], prefix[i], 1e-9) {
+			t.Errorf("Window %v: naive %v, prefix %v", wins[i], naive[i], prefix[i])
+		}
+	}
+}
+
+// exampleLocus is the entropy of chr_4748 (the longest UCE) in example_input.nex
+func exampleLocus(b *testing.B) []float64 {
+	in, err := os.Open("../../example-data/example_input.nex")
+	if err != nil {
+		b.Fatalf("Could not open example input: %s", err)
+	}
+	defer in.Close()
+	nex, err := nexus.Read(in)
+	if err != nil {
+		b.Fatalf("Could not read example input: %s", err)
+	}
+	aln := nex.Alignment().Subseq(1768, 2413)
+	return metrics.SitewiseEntropy(&aln, nex.Letters())
+}
+
+// syntheticLocus is a 5 kb locus of random values
+func syntheticLocus(b *testing.B) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	metric := make([]float64, 5000)
+	for i := range metric {
+		metric[i] = rng.Float64()
+	}
+	return metric
+}
+
+func BenchmarkScore(b *testing.B) {
+	loci := []struct {
+		name  string
+		locus func(*testing.B) []float64
+	}{
+		{"example_input.nex", exampleLocus},
+		{"synthetic5kb", syntheticLocus},
+	}
+	scorers := []struct {
+		name  string
+		score func([]float64, []windows.Window, int, int) []float64
+	}{
+		{"Naive", windows.ScoreNaive},
+		{"Prefix", windows.ScorePrefix},
+	}
+	for _, l := range loci {
+		metric := l.locus(b)
+		wins := candidateWindows(0, len(metric), 50)
+		for _, s := range scorers {
+			b.Run(l.name+"/"+s.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					s.score(metric, wins, 0, len(metric))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package windows
+
+import (
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+)
+
+// Status is whether a UCE was partitioned as found, or why it is used whole
+type Status int
+
+const (
+	// OK is partitioned as found
+	OK Status = iota
+
+	// TooShort is too short to hold the blocks, so used whole
+	TooShort
+
+	// FullRange has a block missing some character, so used whole
+	FullRange
+
+	// Undetermined has a block of only undetermined/ambiguous characters, so used whole
+	Undetermined
+)
+
+// Statuses are all Statuses, in order
+func Statuses() []Status {
+	return []Status{OK, TooShort, FullRange, Undetermined}
+}
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case TooShort:
+		return "too_short"
+	case FullRange:
+		return "full_range"
+	case Undetermined:
+		return "undetermined"
+	default:
+		return ""
+	}
+}
+
+// Check is the Status of the window within the UCE [start, stop)
+func Check(bestWindow Window, start, stop int, aln *nexus.Alignment, chars []byte) Status {
+	return check(blocks(bestWindow, start, stop), aln, chars)
+}
+
+// CheckSegments is the Status of consecutive segments covering a UCE
+func CheckSegments(segs []Window, aln *nexus.Alignment, chars []byte) Status {
+	var bs [][2]int
+	for _, s := range segs {
+		if s.Start() < s.Stop() {
+			bs = append(bs, s)
+		}
+	}
+	return check(bs, aln, chars)
+}
+
+// check is the Status of non-empty blocks, blocks without any determined characters also lack some character
+func check(bs [][2]int, aln *nexus.Alignment, chars []byte) Status {
+	switch {
+	case anyUndeterminedBlocks(bs, aln, chars):
+		return Undetermined
+	case anyBlocksWoAllSites(bs, aln, chars):
+		return FullRange
+	default:
+		return OK
+	}
+}
+
+// Whole is, for each metric, the UCE [start, stop) left unsplit with the given status
+func Whole(mets map[metrics.Metric][]float64, start, stop int, obj Objective, status Status) map[metrics.Metric]Best {
+	whole := New(start, stop)
+	best := make(map[metrics.Metric]Best, len(mets))
+	for m, score := range Score(mets, whole, start, stop, obj) {
+		best[m] = Best{Window: whole, Score: score, Ties: 1, Status: status}
+	}
+	return best
+}
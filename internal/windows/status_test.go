@@ -0,0 +1,55 @@
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/windows"
+)
+
+func TestStatusString(t *testing.T) {
+	exp := []string{"ok", "too_short", "full_range", "undetermined"}
+	for i, s := range windows.Statuses() {
+		if s.String() != exp[i] {
+			t.Errorf("Status %d => Got: %q, Expected: %q", i, s, exp[i])
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	chars := []byte("ACGT")
+	tt := []struct {
+		name string
+		aln  nexus.Alignment
+		exp  windows.Status
+	}{
+		{"all sites", nexus.Alignment{"ACGTACGTACGT", "TGCATGCATGCA"}, windows.OK},
+		{"core lacks a site", nexus.Alignment{"ACGTAAAAACGT", "TGCATTTTTGCA"}, windows.FullRange},
+		{"core undetermined", nexus.Alignment{"ACGT----ACGT", "TGCANNNNTGCA"}, windows.Undetermined},
+	}
+	for _, tc := range tt {
+		if got := windows.Check(windows.New(4, 8), 0, 12, &tc.aln, chars); got != tc.exp {
+			t.Errorf("%s: Got %s, Expected %s", tc.name, got, tc.exp)
+		}
+		segs := []windows.Window{windows.New(0, 4), windows.New(4, 8), windows.New(8, 12)}
+		if got := windows.CheckSegments(segs, &tc.aln, chars); got != tc.exp {
+			t.Errorf("%s segments: Got %s, Expected %s", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestWhole(t *testing.T) {
+	mets := map[metrics.Metric][]float64{
+		metrics.GC:      []float64{0, 0, 1, 1, 2, 2},
+		metrics.Entropy: []float64{5, 5, 5, 5, 5, 5},
+	}
+	got := windows.Whole(mets, 1, 5, windows.SSE, windows.TooShort)
+	exp := map[metrics.Metric]float64{metrics.GC: 2, metrics.Entropy: 0}
+	for m, score := range exp {
+		b := got[m]
+		if b.Window != windows.New(1, 5) || b.Score != score || b.Ties != 1 || b.Status != windows.TooShort {
+			t.Errorf("%s: Got %+v, Expected the whole UCE scoring %v, too short", m, b, score)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package windows_test
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/rhagenson/swsc/internal/metrics"
@@ -30,7 +32,7 @@ func TestGenerateWindows(t *testing.T) {
 		{5786, 100, 15056328},
 	}
 	for _, tc := range tt {
-		got := windows.GenerateWindows(tc.length, tc.minWin)
+		got := windows.GenerateWindows(tc.length, windows.Uniform(tc.minWin))
 		if len(got) != tc.expected {
 			t.Errorf("Given len:%d, win:%d, expected %d, got %d\n",
 				tc.length, tc.minWin, tc.expected, len(got))
@@ -72,7 +74,7 @@ func TestGenerateCandidates(t *testing.T) {
 		{1, 376, 50, 10},
 	}
 	for _, tc := range tt {
-		got := windows.GenerateCandidates(tc.start, tc.stop, tc.minWin)
+		got := windows.GenerateCandidates(tc.start, tc.stop, windows.Uniform(tc.minWin))
 		if len(got) != tc.expected {
 			t.Errorf("Given start:%d, stop:%d, min: %d expected %d, got %d, got %v\n",
 				tc.start, tc.stop, tc.minWin, tc.expected, len(got), got,
@@ -111,7 +113,7 @@ func TestExtendCandidate(t *testing.T) {
 		{windows.Window{100, 200}, 0, 5786, 100, 5151},
 	}
 	for _, tc := range tt {
-		got := windows.ExtendCandidate(tc.win, tc.start, tc.stop, tc.minWin)
+		got := windows.ExtendCandidate(tc.win, tc.start, tc.stop, windows.Uniform(tc.minWin))
 		if len(got) != tc.expected {
 			t.Errorf("Given win:%v, start: %d, stop: %d, min: %d, expected %d, got %d\n",
 				tc.win, tc.start, tc.stop, tc.minWin, tc.expected, len(got),
@@ -151,7 +153,7 @@ func TestGetBest(t *testing.T) {
 	tt := []struct {
 		metrics    map[metrics.Metric][]float64
 		windows    []windows.Window
-		alnLen     int
+		stop       int
 		inVarSites []bool
 		exp        map[metrics.Metric]windows.Window
 		largeCore  bool
@@ -175,20 +177,54 @@ func TestGetBest(t *testing.T) {
 				windows.New(4, 6),
 				windows.New(3, 6),
 			},
-			8,
+			6,
 			[]bool{false, false, false, true, false, true},
 			map[metrics.Metric]windows.Window{
-				metrics.Entropy: windows.New(3, 5),
+				metrics.Entropy: windows.New(2, 4), // Lowest SSE, before variance is considered
 			},
 			false,
 		},
 	}
 
 	for _, tc := range tt {
-		got := windows.GetBest(tc.metrics, tc.windows, tc.alnLen, tc.largeCore)
+		rank := windows.Ranking{Objective: windows.SSE, LargeCore: tc.largeCore, ObjTol: windows.DefaultTol}
+		got := windows.GetBest(tc.metrics, tc.windows, 0, tc.stop, rank)
 		for m, v := range tc.exp {
-			if got[m] != v {
-				t.Errorf("Got: %v, Expected: %v", got[m], v)
+			if got[m].Window != v {
+				t.Errorf("Got: %v, Expected: %v", got[m].Window, v)
+			}
+		}
+	}
+}
+
+// TestGetBestExhaustive checks scanning every window finds the window, score, and ties GetBest finds among them
+func TestGetBestExhaustive(t *testing.T) {
+	const start, stop = 4, 40
+	rng := rand.New(rand.NewSource(1))
+	random := make([]float64, stop)
+	steps := make([]float64, stop) // Blocks of equal values leave many windows tied
+	for i := range random {
+		random[i] = rng.Float64()
+		steps[i] = float64(i / 10)
+	}
+	for _, sizes := range []windows.Sizes{windows.Uniform(5), {MinCore: 8, MinFlank: 3, MaxCore: 15}} {
+		wins := windows.GenerateWindows(stop-start, sizes)
+		for i, w := range wins {
+			wins[i] = windows.New(w.Start()+start, w.Stop()+start)
+		}
+		for name, vals := range map[string][]float64{"random": random, "steps": steps} {
+			mets := map[metrics.Metric][]float64{metrics.GC: vals}
+			for _, obj := range []windows.Objective{windows.SSE, windows.NormSSE, windows.SAD, windows.GaussLL} {
+				for _, largeCore := range []bool{false, true} {
+					for _, tol := range []float64{0, windows.DefaultTol, 0.5} {
+						rank := windows.Ranking{Objective: obj, LargeCore: largeCore, ObjTol: tol, VarTol: tol}
+						got := windows.GetBestExhaustive(mets, start, stop, sizes, rank)[metrics.GC]
+						exp := windows.GetBest(mets, wins, start, stop, rank)[metrics.GC]
+						if !reflect.DeepEqual(got, exp) {
+							t.Errorf("%s %+v %+v: Got %+v, Expected %+v", name, sizes, rank, got, exp)
+						}
+					}
+				}
 			}
 		}
 	}
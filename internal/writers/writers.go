@@ -2,23 +2,39 @@ package writers
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/uce"
 	"github.com/rhagenson/swsc/internal/ui"
 	"github.com/rhagenson/swsc/internal/windows"
 )
 
 // WriteOutputHeader truncates the *write file to only the header row
-func WriteOutputHeader(f io.Writer) {
+// Settings are written first, one per line starting with #, so the run can be reproduced
+func WriteOutputHeader(f io.Writer, settings ...string) {
+	for _, s := range settings {
+		if _, err := fmt.Fprintf(f, "# %s\n", s); err != nil {
+			ui.Errorf("Problem writing output header: %s.", err)
+		}
+	}
 	header := []string{
 		"name",
 		"uce_site", "aln_site",
 		"window_start", "window_stop",
 		"type", "value",
 		"plot_mtx",
+		"posterior",
+		"ties",
+		"scheme",
+		"p_value",
+		"status",
 	}
 	file := csv.NewWriter(f)
 	if err := file.Write(header); err != nil {
@@ -28,8 +44,111 @@ func WriteOutputHeader(f io.Writer) {
 	return
 }
 
+// WriteGapHeader truncates the *write file to only the search gap report header row
+func WriteGapHeader(f io.Writer) {
+	header := []string{
+		"name", "type",
+		"heuristic_start", "heuristic_stop", "heuristic_value",
+		"exhaustive_start", "exhaustive_stop", "exhaustive_value",
+		"gap",
+	}
+	file := csv.NewWriter(f)
+	if err := file.Write(header); err != nil {
+		ui.Errorf("Problem writing gap report header: %s.", err)
+	}
+	file.Flush()
+	return
+}
+
+// Gaps prepares a single UCEs search gap report
+func Gaps(gaps map[metrics.Metric]uce.Gap, name string) [][]string {
+	d := make([][]string, 0, len(gaps))
+	mets := make([]metrics.Metric, 0, len(gaps))
+	for m := range gaps {
+		mets = append(mets, m)
+	}
+	for _, m := range sortMetrics(mets) {
+		g := gaps[m]
+		d = append(d, []string{
+			name,                               // 1) UCE name
+			m.String(),                         // 2) Metric under analysis
+			strconv.Itoa(g.Heuristic.Start()),  // 3) Heuristic best window, start
+			strconv.Itoa(g.Heuristic.Stop()),   // 4) Heuristic best window, stop
+			formatFloat(g.HeuristicScore),      // 5) Heuristic objective value
+			strconv.Itoa(g.Exhaustive.Start()), // 6) Exhaustive best window, start
+			strconv.Itoa(g.Exhaustive.Stop()),  // 7) Exhaustive best window, stop
+			formatFloat(g.ExhaustiveScore),     // 8) Exhaustive objective value
+			formatFloat(g.Diff()),              // 9) How far the heuristic falls from the optimum
+		})
+	}
+	return d
+}
+
+// WriteBootstrapHeader truncates the *write file to only the bootstrap report header row
+func WriteBootstrapHeader(f io.Writer) {
+	header := []string{
+		"name", "type", "boundary",
+		"best", "support", "lower", "upper",
+		"site", "frequency",
+	}
+	file := csv.NewWriter(f)
+	if err := file.Write(header); err != nil {
+		ui.Errorf("Problem writing bootstrap report header: %s.", err)
+	}
+	file.Flush()
+	return
+}
+
+// Bootstrap prepares a single UCEs bootstrap report, one row per position chosen for each core boundary
+// Intervals hold the central level (e.g. 0.95) of replicates
+func Bootstrap(bounds map[metrics.Metric]uce.Boundaries, bestWindows map[metrics.Metric]windows.Best, level float64, name string) [][]string {
+	var d [][]string
+	mets := make([]metrics.Metric, 0, len(bounds))
+	for m := range bounds {
+		mets = append(mets, m)
+	}
+	for _, m := range sortMetrics(mets) {
+		window := bestWindows[m].Window
+		for _, b := range []struct {
+			boundary  string
+			best      int
+			positions []int
+		}{
+			{"start", window.Start(), bounds[m].Starts},
+			{"stop", window.Stop(), bounds[m].Stops},
+		} {
+			lower, upper := uce.Interval(b.positions, level)
+			sites, freqs := uce.Frequencies(b.positions)
+			support := 0.0
+			for i, site := range sites {
+				if site == b.best {
+					support = freqs[i]
+				}
+			}
+			for i, site := range sites {
+				d = append(d, []string{
+					name,                  // 1) UCE name
+					m.String(),            // 2) Metric under analysis
+					b.boundary,            // 3) Core boundary, start or stop
+					strconv.Itoa(b.best),  // 4) Boundary of the best window on the original data
+					formatFloat(support),  // 5) Fraction of replicates choosing the best boundary
+					strconv.Itoa(lower),   // 6) Percentile interval, lower
+					strconv.Itoa(upper),   // 7) Percentile interval, upper
+					strconv.Itoa(site),    // 8) Boundary chosen by a replicate
+					formatFloat(freqs[i]), // 9) Fraction of replicates choosing this boundary
+				})
+			}
+		}
+	}
+	return d
+}
+
 // Output prepares a single UCEs output
-func Output(bestWindows map[metrics.Metric]windows.Window, metricArray map[metrics.Metric][]float64, alnSites []int, name string) [][]string {
+// For a segmentation, the window of each site is its segment and plot_mtx is the segment number (1 to k)
+// pValues are from the permutation test, metrics without one are NA
+// posterior is the chance each site is in the core under an HMM, NA for other algorithms
+// metricArray holds each metric's values at alnSites, in order
+func Output(bestWindows map[metrics.Metric]windows.Best, pValues map[metrics.Metric]float64, metricArray map[metrics.Metric][]float64, alnSites []int, name string) [][]string {
 	d := make([][]string, len(metricArray)*len(alnSites))
 	N := len(alnSites)
 	middle := int(math.Floor(float64(N) / 2.0))
@@ -38,33 +157,109 @@ func Output(bestWindows map[metrics.Metric]windows.Window, metricArray map[metri
 		uceSites[i] = i - middle
 	}
 
-	mNum := 0
-	for m, v := range metricArray {
-		window := bestWindows[m]
+	mets := make([]metrics.Metric, 0, len(metricArray))
+	for m := range metricArray {
+		mets = append(mets, m)
+	}
+	var uceStart, uceStop int
+	if 0 < N {
+		uceStart, uceStop = alnSites[0], alnSites[N-1]+1
+	}
+	for mNum, m := range sortMetrics(mets) {
+		v := metricArray[m]
+		best := bestWindows[m]
+		scheme := strconv.Itoa(best.Window.Blocks(uceStart, uceStop))
+		if best.Segments != nil {
+			scheme = strconv.Itoa(len(best.Segments))
+		}
+		pValue := "NA"
+		if p, ok := pValues[m]; ok {
+			pValue = formatFloat(p)
+		}
 		for i := range alnSites {
-			d[mNum+i] = []string{
-				name,                                  // 1) UCE name
-				strconv.Itoa(uceSites[i]),             // 2) UCE site position relative to center of alignment
-				strconv.Itoa(alnSites[i]),             // 3) UCE site position absolute
-				strconv.Itoa(window.Start()),          // 4) Best window for metric, start
-				strconv.Itoa(window.Stop()),           // 5) Best window for metric, stop
-				m.String(),                            // 6) Metric under analysis
-				strconv.FormatFloat(v[i], 'e', 5, 64), // 7) Metric value at site position
-				strconv.Itoa(relToWindow(window.Start(), i, window.Stop())), // 8) -1 if before window, 0 if in window, 1 if after window
+			window := best.Window
+			plot := relToWindow(window.Start(), alnSites[i], window.Stop())
+			if seg := segmentOf(best.Segments, alnSites[i]); seg != -1 {
+				window = best.Segments[seg]
+				plot = seg + 1
+			}
+			posterior := "NA"
+			if best.Posterior != nil {
+				posterior = formatFloat(best.Posterior[i])
+			}
+			d[mNum*N+i] = []string{
+				name,                         // 1) UCE name
+				strconv.Itoa(uceSites[i]),    // 2) UCE site position relative to center of alignment
+				strconv.Itoa(alnSites[i]),    // 3) UCE site position absolute
+				strconv.Itoa(window.Start()), // 4) Best window (or segment) for metric, start
+				strconv.Itoa(window.Stop()),  // 5) Best window (or segment) for metric, stop
+				m.String(),                   // 6) Metric under analysis
+				formatFloat(v[i]),            // 7) Metric value at site position
+				strconv.Itoa(plot),           // 8) -1 if before window, 0 if in window, 1 if after window (or segment number)
+				posterior,                    // 9) Posterior probability the site is in the core
+				strconv.Itoa(best.Ties),      // 10) Number of windows tied for best
+				scheme,                       // 11) Number of blocks the UCE is partitioned into
+				pValue,                       // 12) Permutation test p-value of the best window
+				best.Status.String(),         // 13) Whether the UCE is partitioned as found, or why it is used whole
 			}
 		}
-		mNum++
 	}
 	return d
 }
 
+// segmentOf is the index of the segment holding the alignment position, -1 if none do
+func segmentOf(segs []windows.Window, pos int) int {
+	for i, s := range segs {
+		if s.Start() <= pos && pos < s.Stop() {
+			return i
+		}
+	}
+	return -1
+}
+
 // relToWindow is a codified function for use in later tools of whether the current alignment position
-// is before (-1), in (0), or after (1) the window
+// is before (-1), in (0), or after (1) the window [start, stop)
 func relToWindow(start, cur, stop int) int {
 	if cur < start {
 		return -1 // Before window
-	} else if stop < cur {
+	} else if stop <= cur {
 		return 1 // After window
 	}
 	return 0 // In Window
 }
+
+// sortMetrics orders metrics so output is reproducible
+func sortMetrics(mets []metrics.Metric) []metrics.Metric {
+	sort.Slice(mets, func(i, j int) bool { return mets[i] < mets[j] })
+	return mets
+}
+
+// formatFloat is the representation of all floating point values in output
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'e', 5, 64)
+}
+
+// WriteAlignment writes the labelled alignment as a Nexus DATA block, the taxon names quoted as needed
+func WriteAlignment(f io.Writer, aln nexus.Labelled, dataType string, gap, missing byte) {
+	width := 0
+	names := make([]string, len(aln.Taxa))
+	for i, name := range aln.Taxa {
+		names[i] = nexus.Quote(name)
+		if width < len(names[i]) {
+			width = len(names[i])
+		}
+	}
+	var block strings.Builder
+	block.WriteString("#NEXUS\n\nBEGIN DATA;\n")
+	fmt.Fprintf(&block, "\tDIMENSIONS NTAX=%d NCHAR=%d;\n", aln.NSeq(), aln.Len())
+	fmt.Fprintf(&block, "\tFORMAT DATATYPE=%s GAP=%c MISSING=%c;\n", dataType, gap, missing)
+	block.WriteString("MATRIX\n")
+	for i, name := range names {
+		fmt.Fprintf(&block, "%-*s    %s\n", width, name, aln.Alignment[i])
+	}
+	block.WriteString(";\nEND;\n")
+	if _, err := io.WriteString(f, block.String()); err != nil {
+		ui.Errorf("Problem writing alignment: %s.", err)
+	}
+	return
+}
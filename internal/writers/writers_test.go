@@ -0,0 +1,242 @@
+package writers_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhagenson/swsc/internal/metrics"
+	"github.com/rhagenson/swsc/internal/nexus"
+	"github.com/rhagenson/swsc/internal/uce"
+	"github.com/rhagenson/swsc/internal/windows"
+	"github.com/rhagenson/swsc/internal/writers"
+)
+
+func TestWriteOutputHeader(t *testing.T) {
+	var plain, withSettings bytes.Buffer
+	writers.WriteOutputHeader(&plain)
+	if !strings.HasPrefix(plain.String(), "name,") {
+		t.Errorf("Got header %q, Expected the column names first", plain.String())
+	}
+	writers.WriteOutputHeader(&withSettings, "minWin=17 candidates=3")
+	exp := "# minWin=17 candidates=3\n" + plain.String()
+	if withSettings.String() != exp {
+		t.Errorf("Got header %q, Expected %q", withSettings.String(), exp)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	mets := map[metrics.Metric][]float64{
+		metrics.GC:      []float64{1, 0, 0, 1},
+		metrics.Entropy: []float64{0, 1, 1, 0},
+	}
+	wins := map[metrics.Metric]windows.Best{
+		metrics.GC:      {Window: windows.New(4, 5), Ties: 1},
+		metrics.Entropy: {Window: windows.New(5, 6), Ties: 3},
+	}
+	alnSites := []int{3, 4, 5, 6}
+	got := writers.Output(wins, map[metrics.Metric]float64{metrics.GC: 0.01}, mets, alnSites, "uce")
+
+	if len(got) != len(mets)*len(alnSites) {
+		t.Fatalf("Expected %d rows, got %d", len(mets)*len(alnSites), len(got))
+	}
+	for i, row := range got {
+		if row == nil {
+			t.Fatalf("Row %d was not written", i)
+		}
+	}
+	t.Run("Metric order", func(t *testing.T) {
+		for i, row := range got {
+			exp := metrics.Entropy.String()
+			if len(alnSites) <= i {
+				exp = metrics.GC.String()
+			}
+			if row[5] != exp {
+				t.Errorf("Row %d: got metric %s, expected %s", i, row[5], exp)
+			}
+		}
+	})
+	t.Run("Values in site order", func(t *testing.T) {
+		exp := []string{"0.00000e+00", "1.00000e+00", "1.00000e+00", "0.00000e+00"}
+		for i := range alnSites {
+			if got[i][6] != exp[i] {
+				t.Errorf("Site %d: got %s, expected %s", alnSites[i], got[i][6], exp[i])
+			}
+		}
+	})
+	t.Run("Position relative to window", func(t *testing.T) {
+		exp := []string{"-1", "-1", "0", "1"} // The window stop is exclusive, so site 6 is after it
+		for i := range alnSites {
+			if got[i][7] != exp[i] {
+				t.Errorf("Site %d: got %s, expected %s", alnSites[i], got[i][7], exp[i])
+			}
+		}
+	})
+	t.Run("Posterior", func(t *testing.T) {
+		for i, row := range got {
+			if row[8] != "NA" {
+				t.Errorf("Row %d: got posterior %s, expected NA", i, row[8])
+			}
+		}
+		post := map[metrics.Metric]windows.Best{
+			metrics.GC: {Window: windows.New(4, 5), Posterior: []float64{0, 0.25, 1, 0.5}},
+		}
+		exp := []string{"0.00000e+00", "2.50000e-01", "1.00000e+00", "5.00000e-01"}
+		for i, row := range writers.Output(post, nil, map[metrics.Metric][]float64{metrics.GC: mets[metrics.GC]}, alnSites, "uce") {
+			if row[8] != exp[i] {
+				t.Errorf("Site %d: got posterior %s, expected %s", alnSites[i], row[8], exp[i])
+			}
+		}
+	})
+	t.Run("Ties", func(t *testing.T) {
+		for i, row := range got {
+			exp := "3"
+			if len(alnSites) <= i {
+				exp = "1"
+			}
+			if row[9] != exp {
+				t.Errorf("Row %d: got %s ties, expected %s", i, row[9], exp)
+			}
+		}
+	})
+	t.Run("P-value", func(t *testing.T) {
+		for i, row := range got {
+			exp := "NA"
+			if len(alnSites) <= i {
+				exp = "1.00000e-02"
+			}
+			if row[11] != exp {
+				t.Errorf("Row %d: got p-value %s, expected %s", i, row[11], exp)
+			}
+		}
+	})
+	t.Run("Status", func(t *testing.T) {
+		for i, row := range got {
+			if row[12] != "ok" {
+				t.Errorf("Row %d: got status %s, expected ok", i, row[12])
+			}
+		}
+		short := map[metrics.Metric]windows.Best{
+			metrics.GC: {Window: windows.New(3, 7), Status: windows.TooShort},
+		}
+		for i, row := range writers.Output(short, nil, map[metrics.Metric][]float64{metrics.GC: mets[metrics.GC]}, alnSites, "uce") {
+			if row[12] != "too_short" {
+				t.Errorf("Row %d: got status %s, expected too_short", i, row[12])
+			}
+		}
+	})
+	t.Run("Scheme", func(t *testing.T) {
+		for i, row := range got {
+			if row[10] != "3" {
+				t.Errorf("Row %d: got scheme %s, expected 3", i, row[10])
+			}
+		}
+		whole := map[metrics.Metric]windows.Best{
+			metrics.GC:      {Window: windows.New(3, 7)},
+			metrics.Entropy: {Window: windows.New(5, 7)},
+		}
+		for i, row := range writers.Output(whole, nil, mets, alnSites, "uce") {
+			exp := "2"
+			if len(alnSites) <= i {
+				exp = "1"
+			}
+			if row[10] != exp {
+				t.Errorf("Row %d: got scheme %s, expected %s", i, row[10], exp)
+			}
+		}
+	})
+}
+
+func TestOutputSegments(t *testing.T) {
+	mets := map[metrics.Metric][]float64{metrics.GC: []float64{0, 0, 1, 1, 0, 0, 1, 1}}
+	segs := []windows.Window{windows.New(2, 4), windows.New(4, 6), windows.New(6, 8)}
+	best := map[metrics.Metric]windows.Best{metrics.GC: {Window: windows.New(2, 8), Segments: segs, Ties: 1}}
+	got := writers.Output(best, nil, mets, []int{2, 3, 4, 5, 6, 7}, "uce")
+	exp := [][]string{
+		{"2", "4", "1"}, {"2", "4", "1"},
+		{"4", "6", "2"}, {"4", "6", "2"},
+		{"6", "8", "3"}, {"6", "8", "3"},
+	}
+	for i, row := range got {
+		if row[3] != exp[i][0] || row[4] != exp[i][1] || row[7] != exp[i][2] {
+			t.Errorf("Row %d: got segment (%s, %s) number %s, expected (%s, %s) number %s",
+				i, row[3], row[4], row[7], exp[i][0], exp[i][1], exp[i][2])
+		}
+		if row[10] != "3" {
+			t.Errorf("Row %d: got scheme %s, expected 3", i, row[10])
+		}
+	}
+}
+
+func TestGaps(t *testing.T) {
+	gaps := map[metrics.Metric]uce.Gap{
+		metrics.GC: {
+			Heuristic: windows.New(50, 100), Exhaustive: windows.New(50, 100),
+			HeuristicScore: 2, ExhaustiveScore: 2,
+		},
+		metrics.Entropy: {
+			Heuristic: windows.New(50, 100), Exhaustive: windows.New(60, 120),
+			HeuristicScore: 3, ExhaustiveScore: 1,
+		},
+	}
+	got := writers.Gaps(gaps, "uce")
+	if len(got) != len(gaps) {
+		t.Fatalf("Expected %d rows, got %d", len(gaps), len(got))
+	}
+	exp := [][]string{
+		{"uce", "Entropy", "50", "100", "3.00000e+00", "60", "120", "1.00000e+00", "2.00000e+00"},
+		{"uce", "GC", "50", "100", "2.00000e+00", "50", "100", "2.00000e+00", "0.00000e+00"},
+	}
+	for i := range exp {
+		for j := range exp[i] {
+			if got[i][j] != exp[i][j] {
+				t.Errorf("Row %d, column %d: got %s, expected %s", i, j, got[i][j], exp[i][j])
+			}
+		}
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	bounds := map[metrics.Metric]uce.Boundaries{
+		metrics.GC: {Starts: []int{50, 50, 50, 60}, Stops: []int{100, 100, 100, 100}},
+	}
+	best := map[metrics.Metric]windows.Best{metrics.GC: {Window: windows.New(50, 100)}}
+	got := writers.Bootstrap(bounds, best, 1, "uce")
+	exp := [][]string{
+		{"uce", "GC", "start", "50", "7.50000e-01", "50", "60", "50", "7.50000e-01"},
+		{"uce", "GC", "start", "50", "7.50000e-01", "50", "60", "60", "2.50000e-01"},
+		{"uce", "GC", "stop", "100", "1.00000e+00", "100", "100", "100", "1.00000e+00"},
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("Expected %d rows, got %d", len(exp), len(got))
+	}
+	for i := range exp {
+		for j := range exp[i] {
+			if got[i][j] != exp[i][j] {
+				t.Errorf("Row %d, column %d: got %s, expected %s", i, j, got[i][j], exp[i][j])
+			}
+		}
+	}
+}
+
+func TestWriteAlignment(t *testing.T) {
+	aln, err := nexus.NewLabelled([]string{"sp1", "genus species"}, nexus.Alignment{"ACGT-", "ACG??"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writers.WriteAlignment(&buf, aln, "DNA", '-', '?')
+
+	// Written alignments read back with their taxon names
+	nex, err := nexus.ReadStrict(&buf)
+	if err != nil {
+		t.Fatalf("Could not read written alignment: %v", err)
+	}
+	if got := nex.Labelled(); !reflect.DeepEqual(got, aln) {
+		t.Errorf("Got %v, Expected %v", got, aln)
+	}
+	if nex.DataType() != "DNA" || nex.Gap() != '-' || nex.Missing() != '?' {
+		t.Errorf("Got format %s %q %q, Expected DNA '-' '?'", nex.DataType(), nex.Gap(), nex.Missing())
+	}
+}
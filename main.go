@@ -4,22 +4,23 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"math"
+	"io/ioutil"
+	"math/rand"
 	"os"
 	"path"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/rhagenson/swsc/internal/metrics"
 	"github.com/rhagenson/swsc/internal/nexus"
 	"github.com/rhagenson/swsc/internal/pfinder"
 	"github.com/rhagenson/swsc/internal/uce"
 	"github.com/rhagenson/swsc/internal/ui"
-	"github.com/rhagenson/swsc/internal/utils"
 	"github.com/rhagenson/swsc/internal/windows"
 	"github.com/rhagenson/swsc/internal/writers"
-	"github.com/shenwei356/bio/seq"
 	"github.com/shenwei356/bio/seqio/fastx"
 	"github.com/spf13/pflag"
 	pb "gopkg.in/cheggaaa/pb.v1"
@@ -31,23 +32,57 @@ var (
 	fFasta  = pflag.String("fasta", "", "Multi-FASTA file to process (.fna/fasta)")
 	fUces   = pflag.String("uces", "", "CSV file with UCE ranges, format: Name,Start,Stop (inclusive)")
 	fOutput = pflag.String("output", "", "Partition file to write (.csv)")
-	fCfg    = pflag.String("cfg", "", "Config file for PartionFinder2 (.cfg)")
+	fCfg    = pflag.String("cfg", "", "Config file for PartionFinder2 (.cfg), one file per metric (e.g. <name>.entropy.cfg) if more than one metric is used")
 )
 
 // General use flags
 var (
-	fMinWin      = pflag.Uint("minWin", 50, "Minimum window size")
+	fMinWin      = pflag.String("minWin", "50", "Minimum window size, or auto to choose from UCE lengths")
+	fMinCore     = pflag.String("minCore", "", "Minimum core size, in sites or as a fraction of each UCE (e.g. 0.1), defaults to minWin")
+	fMinFlank    = pflag.String("minFlank", "", "Minimum flank size, in sites or as a fraction of each UCE (e.g. 0.1), defaults to minWin")
+	fMaxCore     = pflag.String("maxCore", "", "Maximum core size, in sites or as a fraction of each UCE (e.g. 0.5), defaults to no limit")
 	fLargeCore   = pflag.Bool("largeCore", false, "When a small and large core have equivalent metrics, choose the large core")
-	fNCandidates = pflag.Uint("candidates", 3, "Number of best candidates to search with")
+	fNCandidates = pflag.String("candidates", "3", "Number of best candidates to search with, or auto to choose from UCE lengths")
+	fAutoScope   = pflag.String("autoScope", "global", "Choose automatic minWin and candidates from the shortest UCE (global) or from each UCE's own length (uce)")
+	fObjective   = pflag.String("objective", "sse", "Objective minimised over the flanks and core: sse, normsse (mean-normalised SSE), sad (sum of absolute deviations, slow on long UCEs), or gaussll (Gaussian negative log-likelihood)")
+	fObjTol      = pflag.Float64("objTol", windows.DefaultTol, "Windows with objective values within this tolerance are tied")
+	fVarTol      = pflag.Float64("varTol", windows.DefaultTol, "Tied windows with block length variances within this tolerance remain tied")
+	fCriterion   = pflag.String("criterion", "none", "Choose how many blocks per UCE: none (always three), aic, or bic; with a segmentation algorithm, the number of segments unless set by segments")
+	fAlgorithm   = pflag.String("algorithm", "swsc", "Partitioning algorithm: swsc (left flank, core, right flank), dp (optimal segments by dynamic programming), pelt (penalised optimal segments), binseg (binary segmentation), or hmm (left flank, core, right flank by hidden Markov model)")
+	fSegments    = pflag.Uint("segments", 0, "Number of segments per UCE for dp and binseg, 0 chooses by criterion (or penalty without a criterion) for dp or by penalty for binseg")
+	fPenalty     = pflag.Float64("penalty", 0, "Objective cost of each segment for pelt, binseg, and dp without a criterion, 0 uses a BIC-style penalty")
+	fSearch      = pflag.String("search", "heuristic", "Window search: heuristic (candidates plus extension) or exhaustive (every window, finds the true optimum)")
+	fThreads     = pflag.Uint("threads", uint(runtime.GOMAXPROCS(0)), "Number of UCEs to process at once")
+	fGapReport   = pflag.String("gapReport", "", "Report how far the heuristic search falls from the exhaustive optimum for each UCE (.csv)")
+	fBootstrap   = pflag.Uint("bootstrap", 0, "Number of bootstrap replicates of each UCE, reported to bootstrapReport")
+	fResample    = pflag.String("resample", "taxa", "What bootstrap replicates draw with replacement: taxa, sites (within each block of the best window), or both")
+	fSeed        = pflag.Int64("seed", 1, "Random seed for bootstrap replicates and permutations")
+	fBootReport  = pflag.String("bootstrapReport", "", "Report how often each core boundary is chosen across bootstrap replicates, with percentile intervals (.csv)")
+	fPerms       = pflag.Uint("permutations", 0, "Number of site shuffles used to test whether each UCE's best window beats chance")
+	fAlpha       = pflag.Float64("alpha", 0.05, "UCEs whose permutation p-value is above alpha are not split")
+	fOverlap     = pflag.Bool("allow-overlap", false, "Process UCEs that share sites or a start independently, rather than rejecting them")
+	fExclude     = pflag.String("exclude-taxa", "", "File of taxon names to leave out of the analysis, one per line")
+	fInclude     = pflag.String("include-taxa", "", "File of taxon names to analyse, one per line, all others are left out")
+	fAlignment   = pflag.String("alignment", "", "Nexus file to write the analysed alignment to, with taxon names, for PartitionFinder2 (.nex)")
+	fStrict      = pflag.Bool("strict", false, "Reject Nexus files with commands swsc does not understand in the blocks it reads, rather than skipping them")
 	fHelp        = pflag.Bool("help", false, "Print help and exit")
 )
 
-// Metric flags
-var (
-	fEntropy = pflag.Bool("entropy", false, "Calculate Shannon's entropy metric")
-	fGc      = pflag.Bool("gc", false, "Calculate GC content metric")
-	// multi = pflag.Bool("multi", false, "Calculate multinomial distribution metric")
-)
+// bootstrapLevel is the central fraction of bootstrap replicates covered by the reported intervals
+const bootstrapLevel = 0.95
+
+// Metric flags, one per registered metric
+var fMetrics = metricFlags()
+
+// metricFlags creates a boolean flag for each registered metric
+func metricFlags() map[metrics.Metric]*bool {
+	flags := make(map[metrics.Metric]*bool)
+	for _, m := range metrics.Registered() {
+		sm, _ := metrics.Get(m)
+		flags[m] = pflag.Bool(sm.Flag(), false, sm.Usage())
+	}
+	return flags
+}
 
 func setup() {
 	pflag.Parse()
@@ -58,7 +93,7 @@ func setup() {
 
 	// Failure states
 	switch {
-	case (*fNex == "") != (*fFasta == "" && *fUces == ""):  // != used as XOR
+	case (*fNex == "") == (*fFasta == "" && *fUces == ""): // Exactly one input mode must be given
 		pflag.Usage()
 		ui.Errorf("Must provide either nexus, or fasta and uces\n")
 	case *fOutput == "":
@@ -74,21 +109,183 @@ func setup() {
 		ui.Errorf("Output expected to end in .csv, got %s\n", path.Ext(*fOutput))
 	case *fCfg != "" && !strings.HasSuffix(*fCfg, ".cfg"):
 		ui.Errorf("Config expected to end in .cfg, got %s\n", path.Ext(*fCfg))
-	case *fEntropy == *fGc && (*fEntropy || *fGc):
-		ui.Errorf("Only one metric is allowed\n")
-	case !(*fEntropy || *fGc):
+	case *fGapReport != "" && !strings.HasSuffix(*fGapReport, ".csv"):
+		ui.Errorf("Gap report expected to end in .csv, got %s\n", path.Ext(*fGapReport))
+	case *fBootReport != "" && !strings.HasSuffix(*fBootReport, ".csv"):
+		ui.Errorf("Bootstrap report expected to end in .csv, got %s\n", path.Ext(*fBootReport))
+	case (*fBootstrap == 0) != (*fBootReport == ""):
+		ui.Errorf("Must provide both bootstrap and bootstrapReport, or neither\n")
+	case *fAlpha <= 0 || 1 < *fAlpha:
+		ui.Errorf("Alpha must be in (0, 1]\n")
+	case *fPenalty < 0:
+		ui.Errorf("Penalty must not be negative\n")
+	case *fObjTol < 0 || *fVarTol < 0:
+		ui.Errorf("Tolerances must not be negative\n")
+	case *fExclude != "" && *fInclude != "":
+		ui.Errorf("Must provide at most one of exclude-taxa and include-taxa\n")
+	case *fAlignment != "" && !strings.HasSuffix(*fAlignment, ".nex"):
+		ui.Errorf("Alignment expected to end in .nex, got %s\n", path.Ext(*fAlignment))
+	case *fFasta != "" && *fCfg != "" && *fAlignment == "": // PartitionFinder2 needs the alignment as Nexus
+		ui.Errorf("Must provide alignment to write a config from fasta\n")
+	case *fThreads == 0:
+		ui.Errorf("Must use at least one thread\n")
+	case nMetrics() == 0:
 		ui.Errorf("At least one metric is needed\n")
 	}
 }
 
+// printSummary informs the user how many UCEs of each status were found for each metric
+func printSummary(statuses []map[metrics.Metric]windows.Status) {
+	counts := make(map[metrics.Metric][]int)
+	for _, uceStatuses := range statuses {
+		for m, s := range uceStatuses {
+			if counts[m] == nil {
+				counts[m] = make([]int, len(windows.Statuses()))
+			}
+			counts[m][s]++
+		}
+	}
+	mets := make([]metrics.Metric, 0, len(counts))
+	for m := range counts {
+		mets = append(mets, m)
+	}
+	sort.Slice(mets, func(i, j int) bool { return mets[i] < mets[j] })
+
+	names := make([]string, len(windows.Statuses()))
+	for i, s := range windows.Statuses() {
+		names[i] = s.String()
+	}
+	for _, m := range mets {
+		fmt.Print(ui.Summary(m.String(), names, counts[m]))
+	}
+}
+
+// parseAuto converts the value of a count flag, or reports it is auto
+func parseAuto(name, value string) (uint, bool) {
+	if strings.EqualFold(value, "auto") {
+		return 0, true
+	}
+	n, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		ui.Errorf("Invalid %s: expected a whole number or auto, got %q\n", name, value)
+	}
+	return uint(n), false
+}
+
+// autoNames lists the automatic options
+func autoNames(a uce.Auto) string {
+	switch {
+	case a.MinWin && a.Candidates:
+		return "minWin and candidates"
+	case a.MinWin:
+		return "minWin"
+	default:
+		return "candidates"
+	}
+}
+
+// parseLength converts the value of a length flag, unset is zero
+func parseLength(name, value string) uce.Length {
+	if value == "" {
+		return 0
+	}
+	l, err := uce.ParseLength(value)
+	if err != nil {
+		ui.Errorf("Invalid %s: %v\n", name, err)
+	}
+	return l
+}
+
+// readNames reads a file of taxon names, one per line, skipping blank lines and # comments
+func readNames(fname string) []string {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		ui.Errorf("Could not read taxon names: %s", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	return names
+}
+
+// nMetrics is the number of metrics requested
+func nMetrics() int {
+	n := 0
+	for _, f := range fMetrics {
+		if *f {
+			n++
+		}
+	}
+	return n
+}
+
 func main() {
 	// Parse CLI arguments
 	setup()
+	search, err := uce.ParseSearch(*fSearch)
+	if err != nil {
+		ui.Errorf("Invalid search: %v\n", err)
+	}
+	objective, err := windows.ParseObjective(*fObjective)
+	if err != nil {
+		ui.Errorf("Invalid objective: %v\n", err)
+	}
+	criterion, err := windows.ParseCriterion(*fCriterion)
+	if err != nil {
+		ui.Errorf("Invalid criterion: %v\n", err)
+	}
+	resample, err := uce.ParseResample(*fResample)
+	if err != nil {
+		ui.Errorf("Invalid resample: %v\n", err)
+	}
+	algorithm, err := uce.ParseAlgorithm(*fAlgorithm)
+	if err != nil {
+		ui.Errorf("Invalid algorithm: %v\n", err)
+	}
+	if algorithm != uce.SWSC && (*fGapReport != "" || *fBootstrap != 0 || *fPerms != 0) {
+		ui.Errorf("gapReport, bootstrap, and permutations require the swsc algorithm\n")
+	}
+	perUce, err := uce.ParseScope(*fAutoScope)
+	if err != nil {
+		ui.Errorf("Invalid autoScope: %v\n", err)
+	}
+	minWin, autoMinWin := parseAuto("minWin", *fMinWin)
+	candidates, autoCandidates := parseAuto("candidates", *fNCandidates)
+	auto := uce.Auto{MinWin: autoMinWin, Candidates: autoCandidates, PerUce: perUce}
+	opts := uce.Options{
+		MinWin:     minWin,
+		MinCore:    parseLength("minCore", *fMinCore),
+		MinFlank:   parseLength("minFlank", *fMinFlank),
+		MaxCore:    parseLength("maxCore", *fMaxCore),
+		Candidates: candidates,
+		Ranking: windows.Ranking{
+			Objective: objective,
+			LargeCore: *fLargeCore,
+			ObjTol:    *fObjTol,
+			VarTol:    *fVarTol,
+		},
+		Criterion: criterion,
+		Segments:  *fSegments,
+		Penalty:   *fPenalty,
+	}
+	// Fractions of the UCE are only known per UCE, where a longest core below the shortest is raised to it
+	if 1 <= opts.MaxCore && opts.MaxCore.Sites(0) < opts.Sizes(0).MinCore {
+		ui.Errorf("maxCore must be at least minCore\n")
+	}
 
 	var (
-		aln     = new(nexus.Alignment)             // Sequence alignment
-		uces    = make(map[string][]nexus.Pair, 0) // UCE set
-		letters []byte                             // Valid letters in Alignment
+		aln      = new(nexus.Alignment)             // Sequence alignment
+		labelled nexus.Labelled                     // Sequence alignment with taxon names
+		uces     = make(map[string][]nexus.Pair, 0) // UCE set
+		dups     []string                           // UCE names given more than once
+		letters  []byte                             // Valid letters in Alignment
+		dataType = "DNA"                            // Data type of the alignment, as written
+		gap      = byte('-')                        // Gap character of the alignment, as written
+		missing  = byte('?')                        // Missing character of the alignment, as written
 	)
 
 	switch {
@@ -100,9 +297,29 @@ func main() {
 		}
 
 		// Read in the input Nexus file
-		nex := nexus.Read(in)
-		*aln = nex.Alignment()
+		read := nexus.Read
+		if *fStrict {
+			read = nexus.ReadStrict
+		}
+		nex, err := read(in)
+		if err != nil {
+			ui.Errorf("Could not read %s: %v\n", *fNex, err)
+		}
+		labelled = nex.Labelled()
+		if labelled.NSeq() == 0 {
+			ui.Errorf("No MATRIX found in a DATA or CHARACTERS block of %s\n", *fNex)
+		}
+		if nex.DataType() != "" {
+			dataType = nex.DataType()
+		}
+		if nex.Gap() != 0 {
+			gap = nex.Gap()
+		}
+		if nex.Missing() != 0 {
+			missing = nex.Missing()
+		}
 		uces = nex.Charsets()
+		dups = nex.DuplicateCharsets()
 		letters = nex.Letters()
 	case *fFasta != "" && *fUces != "": // FASTA and UCE input,
 		fna, err := fastx.NewDefaultReader(*fFasta)
@@ -111,6 +328,7 @@ func main() {
 			ui.Errorf("Could not read input file: %s", err)
 		}
 		seqs := make([]string, 0)
+		taxa := make([]string, 0)
 		for {
 			record, err := fna.Read()
 			if err != nil {
@@ -119,10 +337,14 @@ func main() {
 				}
 				ui.Errorf("Failed parsing FASTA: %v", err)
 			}
-			seqs = append(seqs, record.Seq.String())
+			taxa = append(taxa, string(record.ID))
+			seqs = append(seqs, strings.ToUpper(string(record.Seq.Seq)))
+		}
+		labelled, err = nexus.NewLabelled(taxa, nexus.Alignment(seqs))
+		if err != nil {
+			ui.Errorf("Could not read %s: %v\n", *fFasta, err)
 		}
-		*aln = nexus.Alignment(seqs)
-		letters = seq.DNA.Letters()
+		letters = []byte("ATGC") // As for a Nexus DNA alignment, sequences are upper case
 
 		inUce, err := os.Open(*fUces)
 		defer inUce.Close()
@@ -154,6 +376,9 @@ func main() {
 			if err != nil {
 				ui.Errorf("Failed to read Stop in UCE row: %q", row)
 			}
+			if _, ok := uces[row[colMap["Name"]]]; ok {
+				dups = append(dups, row[colMap["Name"]])
+			}
 			uces[row[colMap["Name"]]] = append(uces[row[colMap["Name"]]],
 				nexus.NewPair(
 					start,
@@ -165,121 +390,214 @@ func main() {
 		ui.Errorf("Did not understand how to read input")
 	}
 
+	// Leave out taxa by name, recorded in the output header
+	var settings []string
+	switch {
+	case *fExclude != "":
+		labelled, err = labelled.Exclude(readNames(*fExclude))
+		settings = append(settings, "exclude-taxa="+*fExclude)
+	case *fInclude != "":
+		labelled, err = labelled.Include(readNames(*fInclude))
+		settings = append(settings, "include-taxa="+*fInclude)
+	}
+	if err != nil {
+		ui.Errorf("Could not select taxa: %v\n", err)
+	}
+	if labelled.NSeq() == 0 {
+		ui.Errorf("No taxa left to analyse\n")
+	}
+	*aln = labelled.Alignment
+	if *fAlignment != "" {
+		alnFile, err := os.Create(*fAlignment)
+		if err != nil {
+			ui.Errorf("Could not create alignment file: %s", err)
+		}
+		writers.WriteAlignment(alnFile, labelled, dataType, gap, missing)
+		alnFile.Close()
+	}
+	if err := uce.Validate(uces, dups, aln.Len(), *fOverlap); err != nil {
+		ui.Errorf("Invalid UCEs: %v\n", err)
+	}
+
 	out, err := os.Create(*fOutput)
 	defer out.Close()
 	if err != nil {
 		ui.Errorf("Could not create output file: %s", err)
 	}
 
-	writers.WriteOutputHeader(out)
+	// Automatic options follow the README guidance, minWin*candidates about a third of the shortest UCE
+	if auto.Any() {
+		lengths := make([]int, 0, len(uces))
+		for _, sites := range uces {
+			lengths = append(lengths, uce.NewLocus(sites).Len())
+		}
+		sort.Ints(lengths)
+		var msg string
+		if auto.PerUce || len(lengths) == 0 {
+			var low, high uce.Options
+			if 0 < len(lengths) {
+				low, high = auto.Tune(opts, lengths[0]), auto.Tune(opts, lengths[len(lengths)-1])
+			}
+			msg = fmt.Sprintf("minWin=%s candidates=%s autoScope=%s (minWin %d to %d, candidates %d to %d)",
+				*fMinWin, *fNCandidates, auto.Scope(), low.MinWin, high.MinWin, low.Candidates, high.Candidates)
+		} else {
+			opts = auto.Tune(opts, lengths[0])
+			msg = fmt.Sprintf("minWin=%d candidates=%d (%s from the shortest UCE of %d sites)",
+				opts.MinWin, opts.Candidates, autoNames(auto), lengths[0])
+		}
+		fmt.Printf("Chose %s\n", msg)
+		settings = append(settings, msg)
+	}
+	writers.WriteOutputHeader(out, settings...)
 
-	var (
-		bar     = pb.StartNew(len(uces)) // Progress bar
-		metVals = make(map[metrics.Metric][]float64, 3)
-	)
+	mets := make([]metrics.Metric, 0, nMetrics())
 
-	// Early panic if minWin has been set too large to create flanks and core of that length
-	if err := utils.ValidateMinWin(aln.Len(), int(*fMinWin)); err != nil {
-		ui.Errorf("Failed due to: %v", err)
+	for m, f := range fMetrics {
+		if !*f {
+			continue
+		}
+		if err := metrics.Validate(m, letters); err != nil {
+			ui.Errorf("Invalid metric choice: %v\n", err)
+		}
+		mets = append(mets, m)
 	}
 
-	if *fEntropy {
-		metVals[metrics.Entropy] = metrics.SitewiseEntropy(aln, letters)
+	// Sort UCEs by start, UCEs sharing a start (with allow-overlap) are processed separately
+	names := uce.Order(uces)
+
+	// Process each UCE in turn
+	pFinderConfigBlocks := make(map[metrics.Metric][]string, len(mets))
+	for _, m := range mets {
+		pFinderConfigBlocks[m] = make([]string, len(uces))
 	}
-	if *fGc {
-		metVals[metrics.GC] = metrics.SitewiseGc(aln)
+	outputFrames := make([][][]string, len(uces))
+	gapFrames := make([][][]string, len(uces))
+	bootFrames := make([][][]string, len(uces))
+	statuses := make([]map[metrics.Metric]windows.Status, len(uces))
+	// swscUce finds the best window of the uceNum-th UCE, with sites [0, n) and its alignment aln, along with its optional reports
+	// Reports are mapped back to alignment positions by locus
+	swscUce := func(uceNum int, name string, locus uce.Locus, vals map[metrics.Metric][]float64, aln nexus.Alignment, opts uce.Options) (bestWindows map[metrics.Metric]windows.Best, pValues map[metrics.Metric]float64) {
+		n := locus.Len()
+		bestWindows = search.Process(0, n, vals, opts)
+		if *fGapReport != "" {
+			heuristic, exhaustive := bestWindows, bestWindows
+			if search == uce.Exhaustive {
+				heuristic = uce.Heuristic.Process(0, n, vals, opts)
+			} else {
+				exhaustive = uce.Exhaustive.Process(0, n, vals, opts)
+			}
+			gapFrames[uceNum] = writers.Gaps(uce.Gaps(locus.Best(heuristic), locus.Best(exhaustive)), name)
+		}
+		// Seeded by UCE so random draws do not depend on which worker processes the UCE
+		rng := rand.New(rand.NewSource(*fSeed + int64(uceNum)))
+		if *fBootstrap != 0 {
+			bounds := uce.Bootstrap(aln, letters, 0, n, bestWindows, search, opts, resample, *fBootstrap, rng)
+			bootFrames[uceNum] = writers.Bootstrap(locus.Boundaries(bounds), locus.Best(bestWindows), bootstrapLevel, name)
+		}
+		if *fPerms != 0 {
+			pValues = uce.Permutation(0, n, vals, bestWindows, search, opts, *fPerms, rng)
+		}
+		bestWindows = windows.SelectScheme(vals, bestWindows, 0, n, opts.Sizes(n), opts.Ranking, opts.Criterion)
+		for m, p := range pValues {
+			if *fAlpha < p { // No better than chance, leave the UCE whole
+				bestWindows[m] = windows.Whole(vals, 0, n, opts.Ranking.Objective, windows.OK)[m]
+			}
+		}
+		return bestWindows, pValues
 	}
+	// processUce finds the best windows of the uceNum-th UCE (in start order)
+	// Results are stored by UCE order so output is deterministic regardless of completion order
+	processUce := func(uceNum int) {
+		name := names[uceNum]
+		// A UCE's sites, which may be disjoint ranges, are analysed concatenated as [0, n)
+		// then mapped back to alignment positions for output
+		locus := uce.NewLocus(uces[name])
+		n := locus.Len()
+		locusAln := locus.Alignment(*aln)
+		// Metrics are computed over the UCE's own columns, so no value depends on other UCEs
+		vals := uce.Values(&locusAln, letters, mets)
 
-	// Sort UCEs
-	// Create reverse lookup to maintain order
-	revUCEs := make(map[int]string, len(uces))
-	keys := make([]int, 0, len(uces))
-	for name, sites := range uces {
 		var (
-			start = math.MaxInt64 // Minimum position in UCE
+			bestWindows map[metrics.Metric]windows.Best
+			pValues     map[metrics.Metric]float64
+			opts        = opts
 		)
-		// Get the inclusive window for the UCE if multiple windows exist (which they should not, but can in the Nexus format)
-		for _, pair := range sites {
-			if pair.First() < start {
-				start = pair.First()
+		if auto.PerUce {
+			opts = auto.Tune(opts, n)
+		}
+		switch {
+		case n < algorithm.MinLength(opts.Sizes(n)): // Too short to split, leave the UCE whole
+			bestWindows = windows.Whole(vals, 0, n, opts.Ranking.Objective, windows.TooShort)
+		case algorithm == uce.SWSC:
+			bestWindows, pValues = swscUce(uceNum, name, locus, vals, locusAln, opts)
+		default:
+			bestWindows = algorithm.Segment(0, n, vals, opts)
+		}
+		statuses[uceNum] = make(map[metrics.Metric]windows.Status, len(bestWindows))
+		for m, best := range bestWindows {
+			if best.Status == windows.OK {
+				if best.Segments != nil {
+					best.Status = windows.CheckSegments(best.Segments, &locusAln, letters)
+				} else {
+					best.Status = windows.Check(best.Window, 0, n, &locusAln, letters)
+				}
+				bestWindows[m] = best
 			}
+			statuses[uceNum][m] = best.Status
 		}
-		revUCEs[start] = name
-		keys = append(keys, start)
-	}
-	sort.Ints(keys) // Sort done in place
-
-	// Process each UCE in turn
-	pFinderConfigBlocks := make([]string, len(uces))
-	outputFrames := make([][][]string, len(uces))
-	sem := make(chan struct{}, len(uces))
-	uceNum := 0
-	for _, key := range keys {
-		go func(key, uceNum int) {
-			name := revUCEs[key]
-			sites := uces[name]
-			var (
-				start = sites[0].First()  // Minimum position in UCE
-				stop  = sites[0].Second() // Maximum position in UCE
-			)
-			// Get the inclusive window for the UCE if multiple windows exist (which they should not, but can in the Nexus format)
-			for _, pair := range sites {
-				if pair.First() < start {
-					start = pair.First()
+		if *fCfg != "" {
+			// PartitionFinder2 ranges are 1-based and inclusive, counted along the UCE's sites then mapped to the alignment
+			ranges := pfinder.Map(func(first, last int) [][2]int {
+				rs := locus.Ranges(first-1, last)
+				for i := range rs {
+					rs[i][0]++
 				}
-				if stop < pair.Second() {
-					stop = pair.Second()
+				return rs
+			})
+			for m, best := range bestWindows {
+				bestWindow := best.Window
+				block := ranges.ConfigBlock(
+					name, windows.New(bestWindow.Start()+1, bestWindow.Stop()), 1, n,
+					best.Status != windows.OK,
+				)
+				if best.Segments != nil {
+					segs := make([][2]int, len(best.Segments))
+					for i, seg := range best.Segments {
+						segs[i] = windows.New(seg.Start()+1, seg.Stop())
+					}
+					block = ranges.SegmentBlock(name, segs, best.Status != windows.OK)
 				}
+				pFinderConfigBlocks[m][uceNum] = block
 			}
+		}
+		outputFrames[uceNum] = writers.Output(locus.Best(bestWindows), pValues, vals, locus, name)
+	}
 
-			// Currently uceAln is the subsequence while inVarSites and metVals the entire sequence
-			// It should be the case that processing a UCE considers where the start and stop of the UCE are
-			// finding the best Window within that range
-			bestWindows := uce.ProcessUce(start, stop, metVals, *fMinWin, letters, *fLargeCore, *fNCandidates)
-			if *fCfg != "" {
-				for _, bestWindow := range bestWindows {
-					block := pfinder.ConfigBlock(
-						name, bestWindow, start, stop-1,
-						windows.UseFullRange(bestWindow, aln, letters),
-					)
-					pFinderConfigBlocks[uceNum] = block
-				}
-			}
-			alnSites := make([]int, stop-start)
-			for i := range alnSites {
-				alnSites[i] = i + start
+	// Bounded worker pool, at most fThreads UCEs are processed at once
+	bar := pb.StartNew(len(uces)) // Progress bar, counts completed UCEs
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for t := uint(0); t < *fThreads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for uceNum := range jobs {
+				processUce(uceNum)
+				bar.Increment()
 			}
-			frame := writers.Output(bestWindows, metVals, alnSites, name)
-			outputFrames[uceNum] = frame
-			sem <- struct{}{}
-
-			bar.Increment()
-		}(key, uceNum)
-		uceNum++
+		}()
 	}
-	for i := 0; i < len(uces); i++ {
-		<-sem
+	for uceNum := range names {
+		jobs <- uceNum
 	}
+	close(jobs)
+	wg.Wait()
 	bar.FinishPrint("Finished processing UCEs")
+	printSummary(statuses)
 
 	if *fCfg != "" {
-		pfinderFile, err := os.Create(*fCfg)
-		defer pfinderFile.Close()
-		if err != nil {
-			ui.Errorf("Could not create PartitionFinder2 file: %s", err)
-		}
-		block := pfinder.StartBlock(strings.TrimRight(path.Base(*fNex), ".nex"))
-		if _, err := io.WriteString(pfinderFile, block); err != nil {
-			ui.Errorf("Failed to write PartitionFinder2 start block: %s", err)
-		}
-		for _, b := range pFinderConfigBlocks {
-			if _, err := io.WriteString(pfinderFile, b); err != nil {
-				ui.Errorf("Failed to write PartitionFinder2 config block: %s", err)
-			}
-		}
-		block = pfinder.EndBlock()
-		if _, err := io.WriteString(pfinderFile, block); err != nil {
-			ui.Errorf("Failed to write PartitionFinder2 end block: %s", err)
+		for m, blocks := range pFinderConfigBlocks {
+			writeCfg(cfgPath(*fCfg, m, len(pFinderConfigBlocks)), blocks)
 		}
 	}
 
@@ -290,6 +608,71 @@ func main() {
 		}
 	}
 
+	if *fGapReport != "" {
+		gapFile, err := os.Create(*fGapReport)
+		if err != nil {
+			ui.Errorf("Could not create gap report: %s", err)
+		}
+		defer gapFile.Close()
+		writers.WriteGapHeader(gapFile)
+		gapCsv := csv.NewWriter(gapFile)
+		for _, s := range gapFrames {
+			if err := gapCsv.WriteAll(s); err != nil {
+				ui.Errorf("Failed to write gap report: %v", err)
+			}
+		}
+	}
+
+	if *fBootstrap != 0 {
+		bootFile, err := os.Create(*fBootReport)
+		if err != nil {
+			ui.Errorf("Could not create bootstrap report: %s", err)
+		}
+		defer bootFile.Close()
+		writers.WriteBootstrapHeader(bootFile)
+		bootCsv := csv.NewWriter(bootFile)
+		for _, s := range bootFrames {
+			if err := bootCsv.WriteAll(s); err != nil {
+				ui.Errorf("Failed to write bootstrap report: %v", err)
+			}
+		}
+	}
+
 	// Inform user of where output was written
 	fmt.Println(ui.Footer(*fOutput))
 }
+
+// cfgPath is the PartitionFinder2 config file for a metric
+// When more than one metric is in use the metric name is inserted before the extension
+func cfgPath(base string, m metrics.Metric, nMets int) string {
+	if nMets <= 1 {
+		return base
+	}
+	return strings.TrimSuffix(base, ".cfg") + "." + strings.ToLower(m.String()) + ".cfg"
+}
+
+// writeCfg writes a complete PartitionFinder2 config file from its data blocks
+func writeCfg(fname string, blocks []string) {
+	pfinderFile, err := os.Create(fname)
+	if err != nil {
+		ui.Errorf("Could not create PartitionFinder2 file: %s", err)
+	}
+	defer pfinderFile.Close()
+	dataset := *fNex
+	if *fAlignment != "" { // The alignment actually analysed, after leaving out any taxa
+		dataset = *fAlignment
+	}
+	block := pfinder.StartBlock(strings.TrimSuffix(path.Base(dataset), ".nex"))
+	if _, err := io.WriteString(pfinderFile, block); err != nil {
+		ui.Errorf("Failed to write PartitionFinder2 start block: %s", err)
+	}
+	for _, b := range blocks {
+		if _, err := io.WriteString(pfinderFile, b); err != nil {
+			ui.Errorf("Failed to write PartitionFinder2 config block: %s", err)
+		}
+	}
+	block = pfinder.EndBlock()
+	if _, err := io.WriteString(pfinderFile, block); err != nil {
+		ui.Errorf("Failed to write PartitionFinder2 end block: %s", err)
+	}
+}